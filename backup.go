@@ -0,0 +1,81 @@
+package godip
+
+import "fmt"
+
+// BackupRules are the named backup rules that can be selected for a state with Rules.BackupRule.
+var BackupRules = map[string]BackupRule{
+	"Szykman":          SzykmanBackupRule,
+	"AllHold":          AllHoldBackupRule,
+	"CircularMovement": CircularMovementBackupRule,
+}
+
+// circularMovement resolves deps as a circular movement, where all moves succeed, and returns whether it was one.
+func circularMovement(state State, deps []Province) bool {
+	for _, prov := range deps {
+		if order, _, ok := state.Order(prov); ok && order.Type() != Move {
+			return false
+		}
+	}
+	for _, prov := range deps {
+		state.SetResolution(prov, nil)
+	}
+	return true
+}
+
+// convoyParadox returns whether deps contain a convoy.
+func convoyParadox(state State, deps []Province) bool {
+	for _, prov := range deps {
+		if order, _, ok := state.Order(prov); ok && order.Type() == Convoy {
+			return true
+		}
+	}
+	return false
+}
+
+// CircularMovementBackupRule only resolves circular movements, where all the moves succeed, and fails all orders in
+// other paradoxes with ErrUnresolvedParadox.
+func CircularMovementBackupRule(state State, deps []Province) error {
+	if circularMovement(state, deps) {
+		return nil
+	}
+	for _, prov := range deps {
+		if _, _, ok := state.Order(prov); ok {
+			state.SetResolution(prov, ErrUnresolvedParadox)
+		}
+	}
+	return nil
+}
+
+// SzykmanBackupRule resolves circular movements like CircularMovementBackupRule, and convoy paradoxes by making the
+// convoys in them fail, as preferred by the DATC.
+func SzykmanBackupRule(state State, deps []Province) error {
+	if circularMovement(state, deps) {
+		return nil
+	}
+	if convoyParadox(state, deps) {
+		for _, prov := range deps {
+			if order, _, ok := state.Order(prov); ok && order.Type() == Convoy {
+				state.SetResolution(prov, ErrConvoyParadox)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown circular dependency between %v", deps)
+}
+
+// AllHoldBackupRule resolves circular movements like CircularMovementBackupRule, and convoy paradoxes by making all
+// orders in them fail, as in the 1982 rulebook.
+func AllHoldBackupRule(state State, deps []Province) error {
+	if circularMovement(state, deps) {
+		return nil
+	}
+	if convoyParadox(state, deps) {
+		for _, prov := range deps {
+			if _, _, ok := state.Order(prov); ok {
+				state.SetResolution(prov, ErrConvoyParadox)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown circular dependency between %v", deps)
+}
@@ -0,0 +1,36 @@
+// godip-daide hosts a single game for DAIDE clients, adjudicated by godip.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/zond/godip/daide"
+	"github.com/zond/godip/variants"
+)
+
+func main() {
+	addr := flag.String("addr", ":16713", "Address to listen to.")
+	variantName := flag.String("variant", "Classical", "Variant to play.")
+	deadline := flag.Duration("deadline", 0, "Maximum duration to wait for orders in each phase, zero means forever.")
+	flag.Parse()
+
+	variant, found := variants.Variants[*variantName]
+	if !found {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Hosting a game of %v on %v", variant.Name, listener.Addr())
+	srv := &daide.Server{
+		Variant:  variant,
+		Deadline: *deadline,
+	}
+	if err := srv.Serve(listener); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Game over")
+}
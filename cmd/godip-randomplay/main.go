@@ -0,0 +1,67 @@
+// godip-randomplay plays a game of a variant by picking random orders among the options of each nation, and writes
+// the record of it, e.g. to give a new variant games to test against.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/zond/godip/randomplay"
+	"github.com/zond/godip/variants"
+)
+
+// nextGameFile returns the first games/game_N.txt in dir that doesn't exist.
+func nextGameFile(dir string) string {
+	for n := 1; ; n++ {
+		name := filepath.Join(dir, "games", fmt.Sprintf("game_%v.txt", n))
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+func main() {
+	variantName := flag.String("variant", "Classical", "Variant to play.")
+	seed := flag.Int64("seed", 1, "Seed of the random choices, the same seed and options play the same game.")
+	aggression := flag.Float64("aggression", randomplay.DefaultConfig.Aggression, "Probability that a unit moves, or supports a move of its own nation.")
+	convoys := flag.Float64("convoys", randomplay.DefaultConfig.Convoys, "Probability that an army able to move via convoy does.")
+	buildAnywhere := flag.Float64("build-anywhere", randomplay.DefaultConfig.BuildAnywhere, "Probability that a build is placed outside the home centers, when the variant allows it.")
+	years := flag.Int("years", randomplay.DefaultConfig.Years, "Max number of years to play.")
+	out := flag.String("out", "", "File to write the record to, the first free games/game_N.txt in the current directory if empty.")
+	flag.Parse()
+
+	variant, found := variants.Variants[*variantName]
+	if !found {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	record, err := randomplay.Play(variant, randomplay.Config{
+		Seed:          *seed,
+		Aggression:    *aggression,
+		Convoys:       *convoys,
+		BuildAnywhere: *buildAnywhere,
+		Years:         *years,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		*out = nextGameFile(".")
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		log.Fatal(err)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := record.Write(file); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %v phases of %v to %v", len(record.Phases), variant.Name, *out)
+}
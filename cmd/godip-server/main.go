@@ -0,0 +1,58 @@
+// godip-server serves the godip adjudication API as a plain net/http server,
+// without depending on App Engine.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zond/godip/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen to.")
+	maxRequestBytes := flag.Int64("max-request-bytes", 1<<20, "Maximum size of request bodies, larger requests get 413 Request Entity Too Large.")
+	readTimeout := flag.Duration("read-timeout", 10*time.Second, "Maximum duration for reading an entire request.")
+	writeTimeout := flag.Duration("write-timeout", 60*time.Second, "Maximum duration before timing out writes of a response.")
+	idleTimeout := flag.Duration("idle-timeout", 120*time.Second, "Maximum duration to wait for the next request on keep-alive connections.")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Maximum duration to wait for in flight requests when shutting down.")
+	flag.Parse()
+
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      http.MaxBytesHandler(server.NewRouter(), *maxRequestBytes),
+		ReadTimeout:  *readTimeout,
+		WriteTimeout: *writeTimeout,
+		IdleTimeout:  *idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		log.Printf("Listening to %v", *addr)
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	case <-ctx.Done():
+		log.Printf("Shutting down, waiting at most %v for in flight requests", *shutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
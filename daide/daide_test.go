@@ -0,0 +1,220 @@
+package daide
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/variants/classical"
+)
+
+func classicalMapping(t *testing.T) *Mapping {
+	mapping, err := NewMapping(classical.ClassicalVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mapping
+}
+
+func TestTokens(t *testing.T) {
+	for _, i := range []int{0, 1, 1901, 8191, -1, -8192} {
+		if found := Int(i).Int(); found != i {
+			t.Errorf("Wanted %v, got %v", i, found)
+		}
+	}
+	rep := classicalMapping(t).Representation
+	for _, text := range []string{
+		"HLO ( FRA ) ( 1234 ) ( ( LVL 0 ) )",
+		"NME ( 'godip test' ) ( '1.0' )",
+		"SUB ( ( FRA AMY PAR ) MTO BUR ) ( ( ENG FLT ( STP SCS ) ) HLD )",
+		"MIS ( -2 )",
+	} {
+		msg, err := rep.Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted := rep.Format(msg); formatted != text {
+			t.Errorf("Wanted %q, got %q", text, formatted)
+		}
+	}
+	if _, err := rep.Parse("HLO ( XYZZY )"); err == nil {
+		t.Errorf("Wanted an error for an unknown token")
+	}
+}
+
+func TestMDF(t *testing.T) {
+	mapping := classicalMapping(t)
+	if len(mapping.provinces) != 75 {
+		t.Errorf("Wanted 75 provinces, got %v", len(mapping.provinces))
+	}
+	if category := mapping.provinceTokens["stp"].Category(); category != BicoastalSCCategory {
+		t.Errorf("Wanted stp to be a bicoastal supply center, got %x", category)
+	}
+	if category := mapping.provinceTokens["nth"].Category(); category != SeaNonSCCategory {
+		t.Errorf("Wanted nth to be a sea, got %x", category)
+	}
+	mdf := mapping.Representation.Format(mapping.MDF())
+	for _, wanted := range []string{
+		"MDF ( AUS ENG FRA GER ITA RUS TUR ) ",
+		"( FRA PAR BRE MAR ) ",
+		"( ECH ( FLT IRI MID NTH PIC WAL BEL BRE LON ) ) ",
+		"( PAR ( AMY BUR GAS PIC BRE ) ) ",
+		"( ( FLT NCS ) BAR NWY ) ( ( FLT SCS ) BOT FIN LVN ) ",
+		"( BUL ( AMY SER CON GRE RUM ) ( ( FLT ECS ) BLA CON RUM ) ( ( FLT SCS ) AEG CON GRE ) ) ",
+	} {
+		if !strings.Contains(mdf, wanted) {
+			t.Errorf("Wanted %q in %q", wanted, mdf)
+		}
+	}
+}
+
+func TestSubmission(t *testing.T) {
+	mapping := classicalMapping(t)
+	for text, wanted := range map[string][]string{
+		"( ( FRA AMY PAR ) MTO BUR )":                     {"par", "Move", "bur"},
+		"( ( ENG FLT LON ) HLD )":                         {"lon", "Hold"},
+		"( ( RUS FLT ( STP SCS ) ) MTO BOT )":             {"stp/sc", "Move", "bot"},
+		"( ( ENG AMY LON ) CTO NWY VIA ( NTH ) )":         {"lon", "MoveViaConvoy", "nwy"},
+		"( ( ENG FLT NTH ) CVY ( ENG AMY LON ) CTO NWY )": {"nth", "Convoy", "lon", "nwy"},
+		"( ( GER AMY MUN ) SUP ( GER AMY KIE ) MTO BER )": {"mun", "Support", "kie", "ber"},
+		"( ( GER AMY MUN ) SUP ( GER AMY KIE ) )":         {"mun", "Support", "kie", "kie"},
+		"( ( RUS FLT ( STP NCS ) ) BLD )":                 {"stp/nc", "Build", "Fleet"},
+		"( ( GER AMY RUH ) REM )":                         {"ruh", "Disband"},
+		"( ( GER AMY RUH ) RTO KIE )":                     {"ruh", "Move", "kie"},
+	} {
+		msg, err := mapping.Representation.Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sub, err := mapping.Submission(msg)
+		if err != nil {
+			t.Errorf("Decoding %q: %v", text, err)
+			continue
+		}
+		if !reflect.DeepEqual(sub.Bits, wanted) {
+			t.Errorf("Decoding %q: wanted %v, got %v", text, wanted, sub.Bits)
+		}
+	}
+	msg, _ := mapping.Representation.Parse("( FRA WVE )")
+	if sub, err := mapping.Submission(msg); err != nil || !sub.Waive || sub.Power != mapping.Power(godip.France) {
+		t.Errorf("Wanted a waive by France, got %+v, %v", sub, err)
+	}
+}
+
+type testClient struct {
+	t    *testing.T
+	conn *Conn
+	rep  *Representation
+}
+
+func (self *testClient) send(text string) {
+	msg, err := self.rep.Parse(text)
+	if err != nil {
+		self.t.Fatal(err)
+	}
+	if err := self.conn.Send(msg); err != nil {
+		self.t.Fatal(err)
+	}
+}
+
+// expect returns the first received message starting with prefix, skipping other messages.
+func (self *testClient) expect(prefix string) string {
+	for {
+		msg, err := self.conn.Receive()
+		if err != nil {
+			self.t.Fatalf("Waiting for %q: %v", prefix, err)
+		}
+		if text := self.rep.Format(msg); strings.HasPrefix(text, prefix) {
+			return text
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- (&Server{Variant: classical.ClassicalVariant}).Serve(listener)
+	}()
+	joined := []*testClient{}
+	for index := range classical.ClassicalVariant.Nations {
+		conn, rep, err := Dial(listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		c := &testClient{t: t, conn: conn, rep: rep}
+		c.send("NME ( 'client' ) ( '1' )")
+		c.expect("MAP ( 'Classical' )")
+		if index == 0 {
+			c.send("MDF")
+			c.expect("MDF ( AUS ENG FRA GER ITA RUS TUR )")
+		}
+		joined = append(joined, c)
+	}
+	clients := map[string]*testClient{}
+	for _, c := range joined {
+		clients[strings.Fields(c.expect("HLO"))[2]] = c
+	}
+	for _, c := range clients {
+		c.expect("NOW ( SPR 1901 )")
+	}
+
+	france := clients["FRA"]
+	france.send("SUB ( ( FRA AMY PAR ) MTO BUR")
+	france.expect("HUH")
+	france.send("SUB ( ( FRA AMY PAR ) MTO BUR ) ( ( FRA AMY MAR ) MTO PIE ) ( ( GER AMY MUN ) HLD )")
+	if thx := france.expect("THX"); thx != "THX ( ( FRA AMY PAR ) MTO BUR ) ( MBV )" {
+		t.Errorf("Got %q", thx)
+	}
+	france.expect("THX ( ( FRA AMY MAR ) MTO PIE ) ( MBV )")
+	france.expect("THX ( ( GER AMY MUN ) HLD ) ( NYU )")
+	france.send("MIS")
+	if mis := france.expect("MIS"); mis != "MIS ( FRA FLT BRE )" {
+		t.Errorf("Got %q", mis)
+	}
+
+	germany := clients["GER"]
+	germany.send("NOT ( GOF )")
+	germany.expect("YES ( NOT ( GOF ) )")
+	for _, c := range clients {
+		c.send("MIS")
+		mis, err := c.rep.Parse(c.expect("MIS"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		units, _ := mis.Args()
+		sub := Message{SUB}
+		for _, unit := range units[1:] {
+			sub = append(sub, Group(unit, Message{HLD})...)
+		}
+		c.send(c.rep.Format(sub))
+	}
+	germany.send("GOF")
+	if ord := france.expect("ORD ( SPR 1901 ) ( ( FRA AMY PAR )"); ord != "ORD ( SPR 1901 ) ( ( FRA AMY PAR ) MTO BUR ) ( SUC )" {
+		t.Errorf("Got %q", ord)
+	}
+	if ord := france.expect("ORD ( SPR 1901 ) ( ( ITA AMY VEN )"); ord != "ORD ( SPR 1901 ) ( ( ITA AMY VEN ) HLD ) ( SUC )" {
+		t.Errorf("Got %q", ord)
+	}
+	now := france.expect("NOW ( FAL 1901 )")
+	for _, wanted := range []string{"( FRA AMY BUR )", "( FRA AMY PIE )", "( ITA AMY VEN )"} {
+		if !strings.Contains(now, wanted) {
+			t.Errorf("Wanted %q in %q", wanted, now)
+		}
+	}
+
+	germany.conn.Close()
+	france.expect("CCD ( GER )")
+	for _, c := range clients {
+		c.conn.Close()
+	}
+	if err := <-served; err == nil {
+		t.Errorf("Wanted an error when all clients disconnected")
+	}
+}
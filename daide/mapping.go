@@ -0,0 +1,623 @@
+package daide
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+var coastTokens = map[godip.Province]Token{
+	"nc": NCS,
+	"ne": NEC,
+	"ec": ECS,
+	"se": SEC,
+	"sc": SCS,
+	"sw": SWC,
+	"wc": WCS,
+	"nw": NWC,
+}
+
+var coastNames = map[Token]godip.Province{}
+
+func init() {
+	for name, token := range coastTokens {
+		coastNames[token] = name
+	}
+}
+
+// Mapping maps the nations, provinces, unit types, phases and orders of a variant to DAIDE tokens.
+type Mapping struct {
+	Variant        common.Variant
+	Representation *Representation
+	graph          godip.Graph
+	powers         map[godip.Nation]Token
+	nations        map[Token]godip.Nation
+	provinceTokens map[godip.Province]Token
+	provinces      map[Token]godip.Province
+}
+
+// uniqueName returns a three letter upper case name for a nation or province, that isn't a standard token or in
+// taken. Names clashing with other names are replaced by the initials of longName, e.g. "ECH" for "English Channel".
+func uniqueName(name string, longName string, taken map[string]bool) string {
+	letters := func(s string) []byte {
+		result := []byte{}
+		for _, c := range []byte(strings.ToUpper(s)) {
+			if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				result = append(result, c)
+			}
+		}
+		return append(result, 'X', 'X', 'X')
+	}
+	candidates := []string{string(letters(name)[:3])}
+	if words := strings.Fields(longName); len(words) > 1 {
+		first, second := letters(words[0]), letters(words[1])
+		candidates = append(candidates, string([]byte{first[0], second[0], second[1]}), string([]byte{first[0], first[1], second[0]}))
+	}
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	for _, first := range []byte(digits) {
+		for _, second := range []byte(digits) {
+			candidates = append(candidates, string([]byte{letters(name)[0], first, second}))
+		}
+	}
+	for _, candidate := range candidates {
+		if !taken[candidate] {
+			taken[candidate] = true
+			return candidate
+		}
+	}
+	panic(fmt.Errorf("No unique name for %v", name))
+}
+
+func (self *Mapping) category(prov godip.Province) Category {
+	flags := self.graph.AllFlags(prov)
+	coasts := 0
+	for _, coast := range self.graph.Coasts(prov) {
+		if coast != coast.Super() {
+			coasts++
+		}
+	}
+	sc := self.graph.SC(prov) != nil
+	var result Category
+	switch {
+	case coasts > 1:
+		result = BicoastalNonSCCategory
+	case flags[godip.Land] && flags[godip.Sea]:
+		result = CoastalNonSCCategory
+	case flags[godip.Sea]:
+		result = SeaNonSCCategory
+	default:
+		result = InlandNonSCCategory
+	}
+	if sc {
+		result++
+	}
+	return result
+}
+
+// NewMapping returns the mapping for variant. Variants with more than 256 provinces, coasts not named after one of
+// the eight DAIDE coasts or seasons other than spring and fall are not supported.
+func NewMapping(variant common.Variant) (*Mapping, error) {
+	for _, season := range variant.Seasons {
+		if season != godip.Spring && season != godip.Fall {
+			return nil, fmt.Errorf("Season %v of %v is not supported by DAIDE", season, variant.Name)
+		}
+	}
+	result := &Mapping{
+		Variant:        variant,
+		graph:          variant.Graph(),
+		powers:         map[godip.Nation]Token{},
+		nations:        map[Token]godip.Nation{},
+		provinceTokens: map[godip.Province]Token{},
+		provinces:      map[Token]godip.Province{},
+	}
+	taken := map[string]bool{}
+	for _, name := range standardNames {
+		taken[name] = true
+	}
+	custom := map[Token]string{}
+
+	nations := append([]godip.Nation{}, variant.Nations...)
+	sort.Slice(nations, func(i, j int) bool {
+		return nations[i] < nations[j]
+	})
+	for index, nation := range nations {
+		token := Token(PowerCategory)<<8 | Token(index)
+		result.powers[nation] = token
+		result.nations[token] = nation
+		custom[token] = uniqueName(string(nation), "", taken)
+	}
+
+	supers := []godip.Province{}
+	for _, prov := range result.graph.Provinces() {
+		if prov == prov.Super() {
+			supers = append(supers, prov)
+		} else if _, found := coastTokens[prov.Sub()]; !found {
+			return nil, fmt.Errorf("Coast %v of %v is not supported by DAIDE", prov, variant.Name)
+		}
+	}
+	if len(supers) > 256 {
+		return nil, fmt.Errorf("%v has %v provinces, but DAIDE supports at most 256", variant.Name, len(supers))
+	}
+	sort.Slice(supers, func(i, j int) bool {
+		if ci, cj := result.category(supers[i]), result.category(supers[j]); ci != cj {
+			return ci < cj
+		}
+		return supers[i] < supers[j]
+	})
+	for index, prov := range supers {
+		token := Token(result.category(prov))<<8 | Token(index)
+		result.provinceTokens[prov] = token
+		result.provinces[token] = prov
+		custom[token] = uniqueName(string(prov), variant.ProvinceLongNames[prov], taken)
+	}
+	result.Representation = NewRepresentation(custom)
+	return result, nil
+}
+
+// Power returns the token of nation.
+func (self *Mapping) Power(nation godip.Nation) Token {
+	return self.powers[nation]
+}
+
+// Nation returns the nation of a power token.
+func (self *Mapping) Nation(power Token) (godip.Nation, bool) {
+	nation, found := self.nations[power]
+	return nation, found
+}
+
+// Location returns the province token of prov, or a group of the province token and the coast token for coasts.
+func (self *Mapping) Location(prov godip.Province) Message {
+	token := Message{self.provinceTokens[prov.Super()]}
+	if coast := prov.Sub(); coast != "" {
+		return Group(token, Message{coastTokens[coast]})
+	}
+	return token
+}
+
+// Province returns the province of a location as returned by Location.
+func (self *Mapping) Province(location Message) (godip.Province, error) {
+	location = location.Inner()
+	if len(location) == 0 {
+		return "", fmt.Errorf("Missing province")
+	}
+	prov, found := self.provinces[location[0]]
+	if !found {
+		return "", fmt.Errorf("Unknown province %v", self.Representation.Name(location[0]))
+	}
+	if len(location) == 1 {
+		return prov, nil
+	}
+	coast, found := coastNames[location[1]]
+	if len(location) != 2 || !found {
+		return "", fmt.Errorf("Invalid location %v", self.Representation.Format(location))
+	}
+	return prov.Join(coast), nil
+}
+
+func unitTypeToken(typ godip.UnitType) Token {
+	if typ == godip.Fleet {
+		return FLT
+	}
+	return AMY
+}
+
+// Unit returns the unit group, e.g. "( FRA AMY PAR )", of unit in prov.
+func (self *Mapping) Unit(prov godip.Province, unit godip.Unit) Message {
+	return Group(Message{self.Power(unit.Nation), unitTypeToken(unit.Type)}, self.Location(prov))
+}
+
+// Turn returns the turn group, e.g. "( SPR 1901 )", of phase.
+func (self *Mapping) Turn(phase godip.Phase) Message {
+	season := SPR
+	switch {
+	case phase.Type() == godip.Adjustment:
+		season = WIN
+	case phase.Season() == godip.Spring && phase.Type() == godip.Retreat:
+		season = SUM
+	case phase.Season() == godip.Fall && phase.Type() == godip.Movement:
+		season = FAL
+	case phase.Season() == godip.Fall && phase.Type() == godip.Retreat:
+		season = AUT
+	}
+	return Group(Message{season, Int(phase.Year())})
+}
+
+func (self *Mapping) sortedProvinces(provs []godip.Province) []godip.Province {
+	sort.Slice(provs, func(i, j int) bool {
+		if ti, tj := self.provinceTokens[provs[i].Super()], self.provinceTokens[provs[j].Super()]; ti != tj {
+			return ti < tj
+		}
+		return provs[i] < provs[j]
+	})
+	return provs
+}
+
+// MDF returns the map definition message of the variant.
+func (self *Mapping) MDF() Message {
+	powers := Message{}
+	nations := append([]godip.Nation{}, self.Variant.Nations...)
+	sort.Slice(nations, func(i, j int) bool {
+		return self.powers[nations[i]] < self.powers[nations[j]]
+	})
+	homes := map[godip.Nation][]godip.Province{}
+	nonSCs := []godip.Province{}
+	supers := []godip.Province{}
+	for prov := range self.provinceTokens {
+		supers = append(supers, prov)
+		if owner := self.graph.SC(prov); owner == nil {
+			nonSCs = append(nonSCs, prov)
+		} else if _, found := self.powers[*owner]; found {
+			homes[*owner] = append(homes[*owner], prov)
+		} else {
+			homes[""] = append(homes[""], prov)
+		}
+	}
+	scs := Message{}
+	for _, nation := range nations {
+		powers = append(powers, self.Power(nation))
+		group := Message{self.Power(nation)}
+		for _, prov := range self.sortedProvinces(homes[nation]) {
+			group = append(group, self.provinceTokens[prov])
+		}
+		scs = append(scs, Group(group)...)
+	}
+	unowned := Message{UNO}
+	for _, prov := range self.sortedProvinces(homes[""]) {
+		unowned = append(unowned, self.provinceTokens[prov])
+	}
+	scs = append(scs, Group(unowned)...)
+	others := Message{}
+	for _, prov := range self.sortedProvinces(nonSCs) {
+		others = append(others, self.provinceTokens[prov])
+	}
+
+	adjacencies := Message{}
+	for _, prov := range self.sortedProvinces(supers) {
+		adjacency := Message{self.provinceTokens[prov]}
+		if self.graph.AllFlags(prov)[godip.Land] {
+			armyAdjacency := Message{AMY}
+			for _, dst := range self.adjacent(prov, godip.Land) {
+				armyAdjacency = append(armyAdjacency, self.provinceTokens[dst.Super()])
+			}
+			adjacency = append(adjacency, Group(armyAdjacency)...)
+		}
+		for _, coast := range self.sortedProvinces(self.graph.Coasts(prov)) {
+			if !self.graph.Flags(coast)[godip.Sea] {
+				continue
+			}
+			fleetAdjacency := Message{FLT}
+			if coast != prov {
+				fleetAdjacency = Group(Message{FLT, coastTokens[coast.Sub()]})
+			}
+			for _, dst := range self.adjacent(coast, godip.Sea) {
+				fleetAdjacency = append(fleetAdjacency, self.Location(dst)...)
+			}
+			adjacency = append(adjacency, Group(fleetAdjacency)...)
+		}
+		adjacencies = append(adjacencies, Group(adjacency)...)
+	}
+	return Join(Message{MDF}, Group(powers), Group(Group(scs), Group(others)), Group(adjacencies))
+}
+
+// adjacent returns the provinces, or coasts for fleets, that a unit at prov can move to along edges with flag.
+func (self *Mapping) adjacent(prov godip.Province, flag godip.Flag) []godip.Province {
+	found := map[godip.Province]bool{}
+	for dst, flags := range self.graph.Edges(prov, false) {
+		if flags[flag] && self.graph.Flags(dst)[flag] {
+			if flag == godip.Land {
+				dst = dst.Super()
+			}
+			found[dst] = true
+		}
+	}
+	result := []godip.Province{}
+	for dst := range found {
+		result = append(result, dst)
+	}
+	return self.sortedProvinces(result)
+}
+
+// NOW returns the current position message of s, including the possible retreats of dislodged units.
+func (self *Mapping) NOW(s *state.State) Message {
+	result := Join(Message{NOW}, self.Turn(s.Phase()))
+	units := s.Units()
+	provs := []godip.Province{}
+	for prov := range units {
+		provs = append(provs, prov)
+	}
+	for _, prov := range self.sortedProvinces(provs) {
+		result = append(result, self.Unit(prov, units[prov])...)
+	}
+	if s.Phase().Type() == godip.Retreat {
+		dislodgeds := s.Dislodgeds()
+		provs = []godip.Province{}
+		for prov := range dislodgeds {
+			provs = append(provs, prov)
+		}
+		for _, prov := range self.sortedProvinces(provs) {
+			result = append(result, self.retreating(s, prov)...)
+		}
+	}
+	return result
+}
+
+// retreating returns the unit group of the unit dislodged from prov, followed by MRT and the locations it can retreat to.
+func (self *Mapping) retreating(s *state.State, prov godip.Province) Message {
+	unit := s.Dislodgeds()[prov]
+	retreats := Message{}
+	for _, dst := range self.retreats(s, prov, unit) {
+		retreats = append(retreats, self.Location(dst)...)
+	}
+	return Group(self.Unit(prov, unit).Inner(), Message{MRT}, Group(retreats))
+}
+
+func (self *Mapping) retreats(s *state.State, prov godip.Province, unit godip.Unit) []godip.Province {
+	flag := godip.Land
+	if unit.Type == godip.Fleet {
+		flag = godip.Sea
+	}
+	result := []godip.Province{}
+	for _, dst := range self.adjacent(prov, flag) {
+		if _, err := orders.Move(prov, dst).Validate(s); err == nil {
+			result = append(result, dst)
+		}
+	}
+	return result
+}
+
+// SCO returns the supply center ownership message of s.
+func (self *Mapping) SCO(s *state.State) Message {
+	owned := map[godip.Nation][]godip.Province{}
+	for _, prov := range self.graph.AllSCs() {
+		nation, _, found := s.SupplyCenter(prov)
+		if _, isPower := self.powers[nation]; !found || !isPower {
+			nation = ""
+		}
+		owned[nation] = append(owned[nation], prov)
+	}
+	nations := append([]godip.Nation{}, self.Variant.Nations...)
+	sort.Slice(nations, func(i, j int) bool {
+		return self.powers[nations[i]] < self.powers[nations[j]]
+	})
+	result := Message{SCO}
+	for _, nation := range append(nations, "") {
+		token := UNO
+		if nation != "" {
+			token = self.Power(nation)
+		}
+		if len(owned[nation]) == 0 {
+			continue
+		}
+		group := Message{token}
+		for _, prov := range self.sortedProvinces(owned[nation]) {
+			group = append(group, self.provinceTokens[prov.Super()])
+		}
+		result = append(result, Group(group)...)
+	}
+	return result
+}
+
+// Order returns the order group of order, given by unit in the provided phase. convoyPath is the path of sea
+// provinces of moves via convoy.
+func (self *Mapping) Order(phase godip.Phase, order godip.Order, unit godip.Unit, units map[godip.Province]godip.Unit, convoyPath []godip.Province) (Message, error) {
+	encoded, err := orders.Encode(order)
+	if err != nil {
+		return nil, err
+	}
+	bits := make([]godip.Province, len(encoded.Bits))
+	for index, bit := range encoded.Bits {
+		bits[index] = godip.Province(bit)
+	}
+	switch godip.OrderType(bits[1]) {
+	case godip.Build:
+		unit.Type = godip.UnitType(bits[2])
+		return Group(self.Unit(bits[0], unit), Message{BLD}), nil
+	case godip.Disband:
+		if phase.Type() == godip.Adjustment {
+			return Group(self.Unit(bits[0], unit), Message{REM}), nil
+		}
+		return Group(self.Unit(bits[0], unit), Message{DSB}), nil
+	case godip.Hold:
+		return Group(self.Unit(bits[0], unit), Message{HLD}), nil
+	case godip.Move:
+		if phase.Type() == godip.Retreat {
+			return Group(self.Unit(bits[0], unit), Message{RTO}, self.Location(bits[2])), nil
+		}
+		return Group(self.Unit(bits[0], unit), Message{MTO}, self.Location(bits[2])), nil
+	case godip.MoveViaConvoy:
+		path := Message{}
+		for _, prov := range convoyPath {
+			if prov.Super() != bits[2].Super() {
+				path = append(path, self.provinceTokens[prov.Super()])
+			}
+		}
+		return Group(self.Unit(bits[0], unit), Message{CTO}, self.Location(bits[2]), Message{VIA}, Group(path)), nil
+	case godip.Support:
+		supported := self.Unit(bits[2], units[bits[2]])
+		if bits[2] == bits[3] {
+			return Group(self.Unit(bits[0], unit), Message{SUP}, supported), nil
+		}
+		return Group(self.Unit(bits[0], unit), Message{SUP}, supported, Message{MTO, self.provinceTokens[bits[3].Super()]}), nil
+	case godip.Convoy:
+		return Group(self.Unit(bits[0], unit), Message{CVY}, self.Unit(bits[2], units[bits[2]]), Message{CTO, self.provinceTokens[bits[3].Super()]}), nil
+	}
+	return nil, fmt.Errorf("Can't map %v to DAIDE", order)
+}
+
+// Result returns the result group of an order that resolved to err, with RET added if the unit was dislodged.
+func Result(order godip.Order, err error, dislodged bool) Message {
+	result := Message{SUC}
+	if err != nil {
+		switch err.(type) {
+		case godip.ErrBounce:
+			result = Message{BNC}
+		case godip.ErrSupportBroken:
+			result = Message{CUT}
+		default:
+			if order.Type() == godip.Move && (err == godip.ErrMissingConvoyPath || err == godip.ErrConvoyParadox) {
+				result = Message{DSR}
+			} else {
+				result = Message{FLD}
+			}
+		}
+	}
+	if dislodged {
+		result = append(result, RET)
+	}
+	return Group(result)
+}
+
+// Submission is an order submitted by a client.
+type Submission struct {
+	// Power is the power of the ordered unit, or of the waiving power.
+	Power Token
+	// UnitType is the type of the ordered unit.
+	UnitType godip.UnitType
+	// Province is the location of the ordered unit.
+	Province godip.Province
+	// Order is the order token, e.g. MTO or BLD.
+	Order Token
+	// Waive is true if the order is a WVE.
+	Waive bool
+	// Bits are the parts of the order, as accepted by orders.Parser.Parse. Nil for waives.
+	Bits []string
+}
+
+// Submission decodes an order group of a SUB message.
+func (self *Mapping) Submission(order Message) (*Submission, error) {
+	args, err := order.Inner().Args()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 2 && len(args[1]) == 1 && args[1][0] == WVE {
+		return &Submission{Power: args[0].Inner()[0], Order: WVE, Waive: true}, nil
+	}
+	if len(args) < 2 || len(args[1]) != 1 {
+		return nil, fmt.Errorf("Invalid order %v", self.Representation.Format(order))
+	}
+	result := &Submission{Order: args[1][0]}
+	if result.Power, result.UnitType, result.Province, err = self.unit(args[0]); err != nil {
+		return nil, err
+	}
+	src := string(result.Province)
+	switch args[1][0] {
+	case HLD:
+		result.Bits = []string{src, string(godip.Hold)}
+	case MTO, RTO:
+		if len(args) != 3 {
+			return nil, fmt.Errorf("Invalid move %v", self.Representation.Format(order))
+		}
+		dst, err := self.Province(args[2])
+		if err != nil {
+			return nil, err
+		}
+		result.Bits = []string{src, string(godip.Move), string(dst)}
+	case CTO:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("Invalid convoyed move %v", self.Representation.Format(order))
+		}
+		dst, err := self.Province(args[2])
+		if err != nil {
+			return nil, err
+		}
+		result.Bits = []string{src, string(godip.MoveViaConvoy), string(dst)}
+	case SUP:
+		if len(args) != 3 && len(args) != 5 {
+			return nil, fmt.Errorf("Invalid support %v", self.Representation.Format(order))
+		}
+		_, _, supported, err := self.unit(args[2])
+		if err != nil {
+			return nil, err
+		}
+		dst := supported
+		if len(args) == 5 {
+			if len(args[3]) != 1 || args[3][0] != MTO {
+				return nil, fmt.Errorf("Invalid support %v", self.Representation.Format(order))
+			}
+			if dst, err = self.Province(args[4]); err != nil {
+				return nil, err
+			}
+		}
+		result.Bits = []string{src, string(godip.Support), string(supported.Super()), string(dst.Super())}
+	case CVY:
+		if len(args) != 5 || len(args[3]) != 1 || args[3][0] != CTO {
+			return nil, fmt.Errorf("Invalid convoy %v", self.Representation.Format(order))
+		}
+		_, _, convoyed, err := self.unit(args[2])
+		if err != nil {
+			return nil, err
+		}
+		dst, err := self.Province(args[4])
+		if err != nil {
+			return nil, err
+		}
+		result.Bits = []string{src, string(godip.Convoy), string(convoyed.Super()), string(dst.Super())}
+	case DSB, REM:
+		result.Bits = []string{src, string(godip.Disband)}
+	case BLD:
+		result.Bits = []string{src, string(godip.Build), string(result.UnitType)}
+	default:
+		return nil, fmt.Errorf("Invalid order %v", self.Representation.Format(order))
+	}
+	return result, nil
+}
+
+func (self *Mapping) unit(unit Message) (power Token, typ godip.UnitType, prov godip.Province, err error) {
+	unit = unit.Inner()
+	if len(unit) < 3 {
+		err = fmt.Errorf("Invalid unit %v", self.Representation.Format(unit))
+		return
+	}
+	power = unit[0]
+	switch unit[1] {
+	case AMY:
+		typ = godip.Army
+	case FLT:
+		typ = godip.Fleet
+	default:
+		err = fmt.Errorf("Invalid unit type %v", self.Representation.Name(unit[1]))
+		return
+	}
+	prov, err = self.Province(unit[2:])
+	return
+}
+
+// Note returns the order note explaining why an order failed validation with err.
+func Note(err error) Token {
+	switch err {
+	case nil:
+		return MBV
+	case godip.ErrMissingUnit, godip.ErrMissingConvoyee, godip.ErrMissingSupportUnit:
+		return NSU
+	case godip.ErrInvalidSource, godip.ErrInvalidDestination, godip.ErrInvalidTarget:
+		return NSP
+	case godip.ErrIllegalConvoyer:
+		return NAS
+	case godip.ErrIllegalConvoyee:
+		return NSA
+	case godip.ErrIllegalRetreat:
+		return NVR
+	case godip.ErrInvalidPhase:
+		return NRS
+	case godip.ErrMissingSupplyCenter:
+		return NSC
+	case godip.ErrOccupiedSupplyCenter:
+		return ESC
+	case godip.ErrHostileSupplyCenter:
+		return YSC
+	case godip.ErrIllegalBuild:
+		return HSC
+	case godip.ErrMissingSurplus:
+		return NMB
+	case godip.ErrMissingDeficit:
+		return NMR
+	case godip.ErrIllegalUnitType:
+		return CST
+	}
+	return FAR
+}
@@ -0,0 +1,235 @@
+package daide
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Message is a sequence of tokens.
+type Message []Token
+
+// Join returns the concatenation of messages.
+func Join(messages ...Message) Message {
+	result := Message{}
+	for _, msg := range messages {
+		result = append(result, msg...)
+	}
+	return result
+}
+
+// Group returns the concatenation of messages inside brackets.
+func Group(messages ...Message) Message {
+	return Join(Message{BRA}, Join(messages...), Message{KET})
+}
+
+// Args splits the message into its top level parts, each being either a single token or a bracketed group including
+// its brackets.
+func (self Message) Args() ([]Message, error) {
+	result := []Message{}
+	depth := 0
+	start := 0
+	for index, token := range self {
+		switch token {
+		case BRA:
+			depth++
+		case KET:
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("Unbalanced brackets")
+			}
+		}
+		if depth == 0 {
+			result = append(result, self[start:index+1])
+			start = index + 1
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("Unbalanced brackets")
+	}
+	return result, nil
+}
+
+// Inner returns the message without its surrounding brackets, if it has any.
+func (self Message) Inner() Message {
+	if len(self) > 1 && self[0] == BRA && self[len(self)-1] == KET {
+		return self[1 : len(self)-1]
+	}
+	return self
+}
+
+// Text returns the text of a message consisting of text tokens, with or without surrounding brackets.
+func (self Message) Text() string {
+	result := []byte{}
+	for _, token := range self.Inner() {
+		if token.Category() == TextCategory {
+			result = append(result, byte(token))
+		}
+	}
+	return string(result)
+}
+
+// equal returns whether the message has the same tokens as other.
+func (self Message) equal(other Message) bool {
+	if len(self) != len(other) {
+		return false
+	}
+	for index := range self {
+		if self[index] != other[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func (self Message) bytes() []byte {
+	result := make([]byte, 2*len(self))
+	for index, token := range self {
+		binary.BigEndian.PutUint16(result[2*index:], uint16(token))
+	}
+	return result
+}
+
+// MessageType is the type of a frame of the DAIDE client-server protocol.
+type MessageType byte
+
+const (
+	InitialMessage        MessageType = 0
+	RepresentationMessage MessageType = 1
+	DiplomacyMessage      MessageType = 2
+	FinalMessage          MessageType = 3
+	ErrorMessage          MessageType = 4
+)
+
+const (
+	protocolVersion = 1
+	protocolMagic   = 0xda10
+)
+
+// Conn is a connection using the DAIDE client-server protocol.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewConn wraps conn.
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// Dial connects to a DAIDE server and returns the connection and the representation of the game it hosts.
+func Dial(addr string) (*Conn, *Representation, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := NewConn(conn)
+	initial := make([]byte, 4)
+	binary.BigEndian.PutUint16(initial, protocolVersion)
+	binary.BigEndian.PutUint16(initial[2:], protocolMagic)
+	if err := result.WriteFrame(InitialMessage, initial); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	typ, body, err := result.ReadFrame()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if typ != RepresentationMessage {
+		conn.Close()
+		return nil, nil, fmt.Errorf("Expected a representation message, got %v", typ)
+	}
+	custom := map[Token]string{}
+	for index := 0; index+6 <= len(body); index += 6 {
+		custom[Token(binary.BigEndian.Uint16(body[index:]))] = string(body[index+2 : index+5])
+	}
+	return result, NewRepresentation(custom), nil
+}
+
+// Accept performs the server side of the initial handshake, sending rep to the client.
+func (self *Conn) Accept(rep *Representation) error {
+	typ, body, err := self.ReadFrame()
+	if err != nil {
+		return err
+	}
+	if typ != InitialMessage || len(body) != 4 || binary.BigEndian.Uint16(body) != protocolVersion || binary.BigEndian.Uint16(body[2:]) != protocolMagic {
+		self.WriteFrame(ErrorMessage, []byte{0, 1})
+		return fmt.Errorf("Invalid initial message")
+	}
+	body = []byte{}
+	for _, token := range rep.Custom() {
+		entry := make([]byte, 6)
+		binary.BigEndian.PutUint16(entry, uint16(token))
+		copy(entry[2:5], rep.Name(token))
+		body = append(body, entry...)
+	}
+	return self.WriteFrame(RepresentationMessage, body)
+}
+
+// ReadFrame reads a frame from the connection.
+func (self *Conn) ReadFrame() (MessageType, []byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(self.reader, header); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint16(header[2:]))
+	if _, err := io.ReadFull(self.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return MessageType(header[0]), body, nil
+}
+
+// WriteFrame writes a frame to the connection.
+func (self *Conn) WriteFrame(typ MessageType, body []byte) error {
+	if len(body) > 0xffff {
+		return fmt.Errorf("Frame of %v bytes is too long", len(body))
+	}
+	frame := make([]byte, 4+len(body))
+	frame[0] = byte(typ)
+	binary.BigEndian.PutUint16(frame[2:], uint16(len(body)))
+	copy(frame[4:], body)
+	_, err := self.conn.Write(frame)
+	return err
+}
+
+// Send sends msg as a diplomacy message.
+func (self *Conn) Send(msg Message) error {
+	return self.WriteFrame(DiplomacyMessage, msg.bytes())
+}
+
+// Receive returns the next diplomacy message, or io.EOF if the other side sent a final message.
+func (self *Conn) Receive() (Message, error) {
+	for {
+		typ, body, err := self.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case DiplomacyMessage:
+			if len(body)%2 != 0 {
+				return nil, fmt.Errorf("Diplomacy message of odd length %v", len(body))
+			}
+			result := make(Message, len(body)/2)
+			for index := range result {
+				result[index] = Token(binary.BigEndian.Uint16(body[2*index:]))
+			}
+			return result, nil
+		case FinalMessage:
+			return nil, io.EOF
+		case ErrorMessage:
+			return nil, fmt.Errorf("Received error message %v", body)
+		}
+	}
+}
+
+// Close sends a final message and closes the connection.
+func (self *Conn) Close() error {
+	self.WriteFrame(FinalMessage, nil)
+	return self.conn.Close()
+}
@@ -0,0 +1,539 @@
+package daide
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+// phaseOrders are the order tokens allowed in each phase type.
+var phaseOrders = map[godip.PhaseType]map[Token]bool{
+	godip.Movement:   {HLD: true, MTO: true, SUP: true, CVY: true, CTO: true},
+	godip.Retreat:    {RTO: true, DSB: true},
+	godip.Adjustment: {BLD: true, REM: true, WVE: true},
+}
+
+/*
+Server hosts a single game of a variant for DAIDE clients.
+
+The game starts when one client per nation has sent NME, and each phase is adjudicated when all connected powers have
+submitted their orders and none of them has withdrawn its GOF, or when Deadline has passed. Powers whose clients have
+disconnected are in civil disorder and don't hold up the game.
+*/
+type Server struct {
+	Variant common.Variant
+	// Deadline is the longest time a phase may wait for orders, zero means forever.
+	Deadline time.Duration
+}
+
+type client struct {
+	conn     *Conn
+	name     string
+	nation   godip.Nation
+	passcode int
+	notReady bool
+	gone     bool
+}
+
+type event struct {
+	client *client
+	joined bool
+	msg    Message
+	err    error
+}
+
+type game struct {
+	server  *Server
+	mapping *Mapping
+	state   *state.State
+	clients []*client
+	started bool
+	orders  map[godip.Province]godip.Adjudicator
+	waives  map[godip.Nation]int
+	timer   *time.Timer
+}
+
+// Serve accepts clients on listener and runs the game until it ends, and then closes listener.
+func (self *Server) Serve(listener net.Listener) error {
+	defer listener.Close()
+	mapping, err := NewMapping(self.Variant)
+	if err != nil {
+		return err
+	}
+	s, err := self.Variant.Start()
+	if err != nil {
+		return err
+	}
+	g := &game{
+		server:  self,
+		mapping: mapping,
+		state:   s,
+		orders:  map[godip.Province]godip.Adjudicator{},
+		waives:  map[godip.Nation]int{},
+	}
+	events := make(chan event)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				select {
+				case events <- event{err: err}:
+				case <-done:
+				}
+				return
+			}
+			go g.receive(NewConn(conn), events, done)
+		}
+	}()
+	defer func() {
+		for _, c := range g.clients {
+			if !c.gone {
+				c.conn.Close()
+			}
+		}
+	}()
+	for {
+		var deadline <-chan time.Time
+		if g.timer != nil {
+			deadline = g.timer.C
+		}
+		select {
+		case ev := <-events:
+			switch {
+			case ev.client == nil:
+				return ev.err
+			case ev.joined:
+				g.clients = append(g.clients, ev.client)
+			case ev.err != nil:
+				g.disconnect(ev.client)
+			default:
+				g.handle(ev.client, ev.msg)
+			}
+		case <-deadline:
+			g.timer = nil
+			if err := g.process(); err != nil {
+				return err
+			}
+		}
+		if g.started && g.connected() == 0 {
+			return fmt.Errorf("All clients disconnected")
+		}
+		for g.started && !g.ended() && g.ready() {
+			if err := g.process(); err != nil {
+				return err
+			}
+		}
+		if g.started && g.ended() {
+			return nil
+		}
+	}
+}
+
+func (self *game) receive(conn *Conn, events chan event, done chan struct{}) {
+	send := func(ev event) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-done:
+			conn.Close()
+			return false
+		}
+	}
+	if err := conn.Accept(self.mapping.Representation); err != nil {
+		conn.Close()
+		return
+	}
+	c := &client{conn: conn}
+	if !send(event{client: c, joined: true}) {
+		return
+	}
+	for {
+		msg, err := conn.Receive()
+		if err != nil {
+			send(event{client: c, err: err})
+			return
+		}
+		if !send(event{client: c, msg: msg}) {
+			return
+		}
+	}
+}
+
+func (self *game) send(c *client, msgs ...Message) {
+	if c.gone {
+		return
+	}
+	for _, msg := range msgs {
+		c.conn.Send(msg)
+	}
+}
+
+func (self *game) broadcast(msgs ...Message) {
+	for _, c := range self.clients {
+		self.send(c, msgs...)
+	}
+}
+
+func (self *game) connected() int {
+	result := 0
+	for _, c := range self.clients {
+		if !c.gone && c.nation != "" {
+			result++
+		}
+	}
+	return result
+}
+
+func (self *game) disconnect(c *client) {
+	if c.gone {
+		return
+	}
+	c.gone = true
+	c.conn.Close()
+	if c.nation != "" {
+		self.broadcast(Join(Message{CCD}, Group(Message{self.mapping.Power(c.nation)})))
+	} else {
+		self.remove(c)
+	}
+}
+
+func (self *game) remove(c *client) {
+	for index, found := range self.clients {
+		if found == c {
+			self.clients = append(self.clients[:index], self.clients[index+1:]...)
+			return
+		}
+	}
+}
+
+func (self *game) handle(c *client, msg Message) {
+	args, err := msg.Args()
+	if err != nil || len(args) == 0 {
+		self.send(c, Join(Message{HUH}, Group(Message{ERR}, msg)))
+		return
+	}
+	switch args[0][0] {
+	case NME:
+		if self.started || c.name != "" || len(args) < 2 {
+			self.send(c, Join(Message{REJ}, Group(msg)))
+			return
+		}
+		c.name = args[1].Text()
+		self.send(c, Join(Message{YES}, Group(msg)), Join(Message{MAP}, Group(Text(self.server.Variant.Name))))
+		if self.names() == len(self.server.Variant.Nations) {
+			self.start()
+		}
+	case MDF:
+		self.send(c, self.mapping.MDF())
+	case YES:
+		// Clients acknowledge the map with YES ( MAP ( 'name' ) ).
+	case HLO:
+		if c.nation == "" {
+			self.send(c, Join(Message{REJ}, Group(msg)))
+			return
+		}
+		self.send(c, self.hello(c))
+	case NOW:
+		self.send(c, self.mapping.NOW(self.state))
+	case SCO:
+		self.send(c, self.mapping.SCO(self.state))
+	case SUB:
+		if c.nation == "" {
+			self.send(c, Join(Message{REJ}, Group(msg)))
+			return
+		}
+		for _, order := range args[1:] {
+			self.send(c, Join(Message{THX}, order, Group(Message{self.submit(c.nation, order)})))
+		}
+	case MIS:
+		if c.nation == "" {
+			self.send(c, Join(Message{REJ}, Group(msg)))
+			return
+		}
+		self.send(c, self.missing(c.nation))
+	case GOF:
+		c.notReady = false
+		self.send(c, Join(Message{YES}, Group(msg)))
+	case NOT:
+		if len(args) == 2 && len(args[1].Inner()) == 1 && args[1].Inner()[0] == GOF {
+			c.notReady = true
+			self.send(c, Join(Message{YES}, Group(msg)))
+		} else {
+			self.send(c, Join(Message{REJ}, Group(msg)))
+		}
+	default:
+		self.send(c, Join(Message{REJ}, Group(msg)))
+	}
+}
+
+func (self *game) names() int {
+	result := 0
+	for _, c := range self.clients {
+		if c.name != "" {
+			result++
+		}
+	}
+	return result
+}
+
+func (self *game) hello(c *client) Message {
+	variant := Message{LVL, Int(0)}
+	if self.server.Deadline > 0 {
+		variant = Join(Group(variant), Group(Message{MTL, Int(int(self.server.Deadline / time.Second))}))
+	} else {
+		variant = Group(variant)
+	}
+	return Join(Message{HLO}, Group(Message{self.mapping.Power(c.nation)}), Group(Message{Int(c.passcode)}), Group(variant))
+}
+
+func (self *game) start() {
+	nations := append([]godip.Nation{}, self.server.Variant.Nations...)
+	sort.Slice(nations, func(i, j int) bool {
+		return self.mapping.Power(nations[i]) < self.mapping.Power(nations[j])
+	})
+	index := 0
+	for _, c := range self.clients {
+		if c.name != "" {
+			c.nation = nations[index]
+			c.passcode = 1 + rand.Intn(8191)
+			index++
+		}
+	}
+	self.started = true
+	for _, c := range self.clients {
+		if c.nation != "" {
+			self.send(c, self.hello(c))
+		}
+	}
+	self.broadcast(self.mapping.SCO(self.state), self.mapping.NOW(self.state))
+	self.resetTimer()
+}
+
+func (self *game) resetTimer() {
+	if self.timer != nil {
+		self.timer.Stop()
+	}
+	self.timer = nil
+	if self.server.Deadline > 0 {
+		self.timer = time.NewTimer(self.server.Deadline)
+	}
+}
+
+// unit returns the unit that must be ordered at prov in the current phase.
+func (self *game) unit(prov godip.Province) (godip.Unit, godip.Province, bool) {
+	if self.state.Phase().Type() == godip.Retreat {
+		return self.state.Dislodged(prov)
+	}
+	return self.state.Unit(prov)
+}
+
+// balance returns the number of builds (if positive) or disbands (if negative) nation has left to order.
+func (self *game) balance(nation godip.Nation) int {
+	result := 0
+	for _, message := range self.state.Phase().Messages(self.state, nation) {
+		var n int
+		if _, err := fmt.Sscanf(message, "MayBuild:%d", &n); err == nil {
+			result = n
+		} else if _, err := fmt.Sscanf(message, "MustDisband:%d", &n); err == nil {
+			result = -n
+		}
+	}
+	result -= self.waives[nation]
+	for prov, order := range self.orders {
+		switch order.Type() {
+		case godip.Build:
+			if owner, _, _ := self.state.SupplyCenter(prov); owner == nation {
+				result--
+			}
+		case godip.Disband:
+			if unit, _, _ := self.state.Unit(prov); unit.Nation == nation {
+				result++
+			}
+		}
+	}
+	return result
+}
+
+func (self *game) submit(nation godip.Nation, msg Message) Token {
+	phaseType := self.state.Phase().Type()
+	sub, err := self.mapping.Submission(msg)
+	if err != nil {
+		return FAR
+	}
+	if owner, found := self.mapping.Nation(sub.Power); !found || owner != nation {
+		return NYU
+	}
+	if !phaseOrders[phaseType][sub.Order] {
+		return NRS
+	}
+	if sub.Waive {
+		if self.balance(nation) <= 0 {
+			return NMB
+		}
+		self.waives[nation]++
+		return MBV
+	}
+	if sub.Order == BLD {
+		if self.balance(nation) <= 0 {
+			return NMB
+		}
+	} else {
+		unit, _, found := self.unit(sub.Province)
+		if !found || unit.Type != sub.UnitType {
+			return NSU
+		}
+		if unit.Nation != nation {
+			return NYU
+		}
+		if sub.Order == REM && self.balance(nation) >= 0 {
+			return NMR
+		}
+	}
+	order, err := self.server.Variant.Parser.Parse(sub.Bits)
+	if err != nil {
+		return FAR
+	}
+	validNation, err := order.Validate(self.state)
+	if err != nil {
+		return Note(err)
+	}
+	if validNation != nation {
+		return NYU
+	}
+	for prov := range self.orders {
+		if prov.Super() == sub.Province.Super() {
+			delete(self.orders, prov)
+		}
+	}
+	self.orders[sub.Province] = order
+	self.state.SetOrders(self.orders)
+	return MBV
+}
+
+// missing returns the MIS message for nation.
+func (self *game) missing(nation godip.Nation) Message {
+	result := Message{MIS}
+	switch self.state.Phase().Type() {
+	case godip.Movement:
+		for _, prov := range self.unordered(self.state.Units(), nation) {
+			result = append(result, self.mapping.Unit(prov, self.state.Units()[prov])...)
+		}
+	case godip.Retreat:
+		for _, prov := range self.unordered(self.state.Dislodgeds(), nation) {
+			result = append(result, self.mapping.retreating(self.state, prov)...)
+		}
+	case godip.Adjustment:
+		if balance := self.balance(nation); balance != 0 {
+			result = append(result, Group(Message{Int(-balance)})...)
+		}
+	}
+	return result
+}
+
+// unordered returns the provinces of the units of nation that have no orders.
+func (self *game) unordered(units map[godip.Province]godip.Unit, nation godip.Nation) []godip.Province {
+	result := []godip.Province{}
+	for prov, unit := range units {
+		if unit.Nation != nation {
+			continue
+		}
+		ordered := false
+		for orderProv := range self.orders {
+			if orderProv.Super() == prov.Super() {
+				ordered = true
+			}
+		}
+		if !ordered {
+			result = append(result, prov)
+		}
+	}
+	return self.mapping.sortedProvinces(result)
+}
+
+func (self *game) ready() bool {
+	for _, c := range self.clients {
+		if c.gone || c.nation == "" {
+			continue
+		}
+		if c.notReady || len(self.missing(c.nation)) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *game) ended() bool {
+	return self.server.Variant.Result(self.state).Ended()
+}
+
+// process adjudicates the current phase and sends the results to all clients.
+func (self *game) process() error {
+	phase := self.state.Phase()
+	units := self.state.Units()
+	if phase.Type() == godip.Retreat {
+		units = self.state.Dislodgeds()
+	}
+	before := map[godip.Province]godip.Unit{}
+	for prov, unit := range units {
+		before[prov] = unit
+	}
+	scoBefore := self.mapping.SCO(self.state)
+	self.state.SetOrders(self.orders)
+	if err := self.state.Next(); err != nil {
+		return err
+	}
+	applied := self.state.PreviouslyAppliedOrders()
+	provs := []godip.Province{}
+	for prov := range applied {
+		provs = append(provs, prov)
+	}
+	for _, prov := range self.mapping.sortedProvinces(provs) {
+		order := applied[prov]
+		unit, found := before[prov]
+		if order.Type() == godip.Build {
+			owner, _, _ := self.state.SupplyCenter(prov)
+			unit, found = godip.Unit{Nation: owner}, true
+		}
+		if !found {
+			continue
+		}
+		var convoyPath []godip.Province
+		if explanation := self.state.Explanations()[prov]; explanation != nil {
+			convoyPath = explanation.ConvoyPath
+		}
+		encoded, err := self.mapping.Order(phase, order, unit, before, convoyPath)
+		if err != nil {
+			continue
+		}
+		_, dislodged := self.state.Dislodgeds()[prov]
+		dislodged = dislodged && phase.Type() == godip.Movement
+		self.broadcast(Join(Message{ORD}, self.mapping.Turn(phase), encoded, Result(order, self.state.Resolutions()[prov], dislodged)))
+	}
+	self.orders = map[godip.Province]godip.Adjudicator{}
+	self.waives = map[godip.Nation]int{}
+	for _, c := range self.clients {
+		c.notReady = false
+	}
+	if sco := self.mapping.SCO(self.state); !sco.equal(scoBefore) {
+		self.broadcast(sco)
+	}
+	self.broadcast(self.mapping.NOW(self.state))
+	self.resetTimer()
+	if result := self.server.Variant.Result(self.state); result.Ended() {
+		if result.SoloWinner != "" {
+			self.broadcast(Join(Message{SLO}, Group(Message{self.mapping.Power(result.SoloWinner)})))
+		} else {
+			self.broadcast(Message{DRW})
+		}
+		self.broadcast(Message{OFF})
+	}
+	return nil
+}
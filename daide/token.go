@@ -0,0 +1,313 @@
+package daide
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Token is a DAIDE token, as defined by the DAIDE message syntax.
+type Token uint16
+
+// Category is the high byte of a token, defining what kind of token it is.
+type Category byte
+
+const (
+	BracketCategory        Category = 0x40
+	PowerCategory          Category = 0x41
+	UnitTypeCategory       Category = 0x42
+	OrderCategory          Category = 0x43
+	OrderNoteCategory      Category = 0x44
+	ResultCategory         Category = 0x45
+	CoastCategory          Category = 0x46
+	PhaseCategory          Category = 0x47
+	CommandCategory        Category = 0x48
+	ParameterCategory      Category = 0x49
+	TextCategory           Category = 0x4b
+	InlandNonSCCategory    Category = 0x50
+	InlandSCCategory       Category = 0x51
+	SeaNonSCCategory       Category = 0x52
+	SeaSCCategory          Category = 0x53
+	CoastalNonSCCategory   Category = 0x54
+	CoastalSCCategory      Category = 0x55
+	BicoastalNonSCCategory Category = 0x56
+	BicoastalSCCategory    Category = 0x57
+)
+
+const (
+	BRA Token = 0x4000
+	KET Token = 0x4001
+
+	AMY Token = 0x4200
+	FLT Token = 0x4201
+
+	CTO Token = 0x4320
+	CVY Token = 0x4321
+	HLD Token = 0x4322
+	MTO Token = 0x4323
+	SUP Token = 0x4324
+	VIA Token = 0x4325
+	DSB Token = 0x4340
+	RTO Token = 0x4341
+	BLD Token = 0x4380
+	REM Token = 0x4381
+	WVE Token = 0x4382
+
+	MBV Token = 0x4400
+	BPR Token = 0x4401
+	CST Token = 0x4402
+	ESC Token = 0x4403
+	FAR Token = 0x4404
+	HSC Token = 0x4405
+	NAS Token = 0x4406
+	NMB Token = 0x4407
+	NMR Token = 0x4408
+	NRN Token = 0x4409
+	NRS Token = 0x440a
+	NSA Token = 0x440b
+	NSC Token = 0x440c
+	NSF Token = 0x440d
+	NSP Token = 0x440e
+	NST Token = 0x440f
+	NSU Token = 0x4410
+	NVR Token = 0x4411
+	NYU Token = 0x4412
+	YSC Token = 0x4413
+
+	SUC Token = 0x4500
+	BNC Token = 0x4501
+	CUT Token = 0x4502
+	DSR Token = 0x4503
+	FLD Token = 0x4504
+	NSO Token = 0x4505
+	RET Token = 0x4506
+
+	NCS Token = 0x4600
+	NEC Token = 0x4602
+	ECS Token = 0x4604
+	SEC Token = 0x4606
+	SCS Token = 0x4608
+	SWC Token = 0x460a
+	WCS Token = 0x460c
+	NWC Token = 0x460e
+
+	SPR Token = 0x4700
+	SUM Token = 0x4701
+	FAL Token = 0x4702
+	AUT Token = 0x4703
+	WIN Token = 0x4704
+
+	CCD Token = 0x4800
+	DRW Token = 0x4801
+	FRM Token = 0x4802
+	GOF Token = 0x4803
+	HLO Token = 0x4804
+	HST Token = 0x4805
+	HUH Token = 0x4806
+	IAM Token = 0x4807
+	LOD Token = 0x4808
+	MAP Token = 0x4809
+	MDF Token = 0x480a
+	MIS Token = 0x480b
+	NME Token = 0x480c
+	NOT Token = 0x480d
+	NOW Token = 0x480e
+	OBS Token = 0x480f
+	OFF Token = 0x4810
+	ORD Token = 0x4811
+	OUT Token = 0x4812
+	PRN Token = 0x4813
+	REJ Token = 0x4814
+	SCO Token = 0x4815
+	SLO Token = 0x4816
+	SND Token = 0x4817
+	SUB Token = 0x4818
+	SVE Token = 0x4819
+	THX Token = 0x481a
+	TME Token = 0x481b
+	YES Token = 0x481c
+	ADM Token = 0x481d
+	SMR Token = 0x481e
+
+	AOA Token = 0x4900
+	BTL Token = 0x4901
+	ERR Token = 0x4902
+	LVL Token = 0x4903
+	MRT Token = 0x4904
+	MTL Token = 0x4905
+	NPB Token = 0x4906
+	NPR Token = 0x4907
+	PDA Token = 0x4908
+	PTL Token = 0x4909
+	RTL Token = 0x490a
+	UNO Token = 0x490b
+	DSD Token = 0x490d
+)
+
+var standardNames = map[Token]string{
+	AMY: "AMY", FLT: "FLT",
+	CTO: "CTO", CVY: "CVY", HLD: "HLD", MTO: "MTO", SUP: "SUP", VIA: "VIA", DSB: "DSB", RTO: "RTO", BLD: "BLD", REM: "REM", WVE: "WVE",
+	MBV: "MBV", BPR: "BPR", CST: "CST", ESC: "ESC", FAR: "FAR", HSC: "HSC", NAS: "NAS", NMB: "NMB", NMR: "NMR", NRN: "NRN",
+	NRS: "NRS", NSA: "NSA", NSC: "NSC", NSF: "NSF", NSP: "NSP", NST: "NST", NSU: "NSU", NVR: "NVR", NYU: "NYU", YSC: "YSC",
+	SUC: "SUC", BNC: "BNC", CUT: "CUT", DSR: "DSR", FLD: "FLD", NSO: "NSO", RET: "RET",
+	NCS: "NCS", NEC: "NEC", ECS: "ECS", SEC: "SEC", SCS: "SCS", SWC: "SWC", WCS: "WCS", NWC: "NWC",
+	SPR: "SPR", SUM: "SUM", FAL: "FAL", AUT: "AUT", WIN: "WIN",
+	CCD: "CCD", DRW: "DRW", FRM: "FRM", GOF: "GOF", HLO: "HLO", HST: "HST", HUH: "HUH", IAM: "IAM", LOD: "LOD", MAP: "MAP",
+	MDF: "MDF", MIS: "MIS", NME: "NME", NOT: "NOT", NOW: "NOW", OBS: "OBS", OFF: "OFF", ORD: "ORD", OUT: "OUT", PRN: "PRN",
+	REJ: "REJ", SCO: "SCO", SLO: "SLO", SND: "SND", SUB: "SUB", SVE: "SVE", THX: "THX", TME: "TME", YES: "YES", ADM: "ADM",
+	SMR: "SMR",
+	AOA: "AOA", BTL: "BTL", ERR: "ERR", LVL: "LVL", MRT: "MRT", MTL: "MTL", NPB: "NPB", NPR: "NPR", PDA: "PDA", PTL: "PTL",
+	RTL: "RTL", UNO: "UNO", DSD: "DSD",
+}
+
+// Category returns the category of the token.
+func (self Token) Category() Category {
+	return Category(self >> 8)
+}
+
+// IsInteger returns whether the token is an integer.
+func (self Token) IsInteger() bool {
+	return self < 0x4000
+}
+
+// IsProvince returns whether the token is a province.
+func (self Token) IsProvince() bool {
+	return self.Category() >= InlandNonSCCategory && self.Category() <= BicoastalSCCategory
+}
+
+// Int returns the integer token for i, which must be between -8192 and 8191.
+func Int(i int) Token {
+	return Token(i & 0x3fff)
+}
+
+// Int returns the value of an integer token.
+func (self Token) Int() int {
+	if self&0x2000 != 0 {
+		return int(self) - 0x4000
+	}
+	return int(self)
+}
+
+// Text returns the text tokens for s.
+func Text(s string) Message {
+	result := make(Message, 0, len(s))
+	for _, c := range []byte(s) {
+		result = append(result, Token(TextCategory)<<8|Token(c))
+	}
+	return result
+}
+
+// Representation contains the names of the tokens of a game, i.e. the standard tokens and the powers and provinces of
+// the map being played.
+type Representation struct {
+	names  map[Token]string
+	tokens map[string]Token
+}
+
+// NewRepresentation returns a representation with the standard tokens and the provided powers and provinces.
+func NewRepresentation(custom map[Token]string) *Representation {
+	result := &Representation{
+		names:  map[Token]string{},
+		tokens: map[string]Token{},
+	}
+	for token, name := range standardNames {
+		result.add(token, name)
+	}
+	for token, name := range custom {
+		result.add(token, name)
+	}
+	return result
+}
+
+func (self *Representation) add(token Token, name string) {
+	self.names[token] = name
+	self.tokens[strings.ToUpper(name)] = token
+}
+
+// Custom returns the powers and provinces of the representation, sorted by token.
+func (self *Representation) Custom() (result []Token) {
+	for token := range self.names {
+		if _, found := standardNames[token]; !found {
+			result = append(result, token)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// Name returns the name of the token, or its hexadecimal value if it is unknown.
+func (self *Representation) Name(token Token) string {
+	if name, found := self.names[token]; found {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", uint16(token))
+}
+
+// Format returns the text form of msg, e.g. "HLO ( ENG ) ( 1234 ) ( ( LVL 0 ) )".
+func (self *Representation) Format(msg Message) string {
+	parts := []string{}
+	for index := 0; index < len(msg); index++ {
+		token := msg[index]
+		switch {
+		case token == BRA:
+			parts = append(parts, "(")
+		case token == KET:
+			parts = append(parts, ")")
+		case token.IsInteger():
+			parts = append(parts, strconv.Itoa(token.Int()))
+		case token.Category() == TextCategory:
+			text := []byte{}
+			for ; index < len(msg) && msg[index].Category() == TextCategory; index++ {
+				text = append(text, byte(msg[index]))
+			}
+			index--
+			parts = append(parts, "'"+string(text)+"'")
+		default:
+			parts = append(parts, self.Name(token))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Parse returns the message with the text form s.
+func (self *Representation) Parse(s string) (Message, error) {
+	result := Message{}
+	for index := 0; index < len(s); {
+		c := s[index]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			index++
+		case c == '(':
+			result = append(result, BRA)
+			index++
+		case c == ')':
+			result = append(result, KET)
+			index++
+		case c == '\'':
+			end := strings.IndexByte(s[index+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("Unterminated text in %q", s)
+			}
+			result = append(result, Text(s[index+1:index+1+end])...)
+			index += end + 2
+		default:
+			end := index
+			for end < len(s) && !strings.ContainsRune(" \t\n\r()'", rune(s[end])) {
+				end++
+			}
+			word := s[index:end]
+			if i, err := strconv.Atoi(word); err == nil {
+				result = append(result, Int(i))
+			} else if token, found := self.tokens[strings.ToUpper(word)]; found {
+				result = append(result, token)
+			} else {
+				return nil, fmt.Errorf("Unknown token %q in %q", word, s)
+			}
+			index = end
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package datc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+)
+
+// resultMatches returns whether err is the resolution expected by result, as described for NationalizedOrder.Result.
+func resultMatches(result string, err error) bool {
+	if result == "OK" {
+		return err == nil
+	}
+	if err == nil {
+		return false
+	}
+	if strings.Contains(result, ":") {
+		return err.Error() == result
+	}
+	return strings.Split(err.Error(), ":")[0] == result
+}
+
+/*
+Check returns the differences between the post state of the pair and s, which is expected to be the pre state after
+Next has been called.
+
+Units and dislodged units are always compared. Supply center owners and force disbanded units are only compared if
+the case has POSTSTATE_SUPPLYCENTER_OWNERS or POSTSTATE_FORCE_DISBANDED sections, and order resolutions only for
+orders with RESULT expectations.
+*/
+func (self *StatePair) Check(s *state.State) (result []error) {
+	for prov, unit := range self.After.Units {
+		if found, ok := s.Units()[prov]; !ok {
+			result = append(result, fmt.Errorf("Expected %v in %v, but found nothing", unit, prov))
+		} else if !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Expected %v in %v, but found %v", unit, prov, found))
+		}
+	}
+	for prov, unit := range self.After.Dislodgeds {
+		if found, ok := s.Dislodgeds()[prov]; !ok {
+			result = append(result, fmt.Errorf("Expected %v dislodged in %v, but found nothing", unit, prov))
+		} else if !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Expected %v dislodged in %v, but found %v", unit, prov, found))
+		}
+	}
+	for prov, unit := range s.Units() {
+		if _, ok := self.After.Units[prov]; !ok {
+			result = append(result, fmt.Errorf("Expected %v to be empty, but found %v", prov, unit))
+		}
+	}
+	for prov, unit := range s.Dislodgeds() {
+		if _, ok := self.After.Dislodgeds[prov]; !ok {
+			result = append(result, fmt.Errorf("Expected %v to be empty of dislodged units, but found %v", prov, unit))
+		}
+	}
+	if self.After.SCs != nil {
+		for prov, nation := range self.After.SCs {
+			if found, ok := s.SupplyCenters()[prov]; !ok {
+				result = append(result, fmt.Errorf("Expected %v to own %v, but it was unowned", nation, prov))
+			} else if found != nation {
+				result = append(result, fmt.Errorf("Expected %v to own %v, but found %v", nation, prov, found))
+			}
+		}
+		for prov, nation := range s.SupplyCenters() {
+			if _, ok := self.After.SCs[prov]; !ok {
+				result = append(result, fmt.Errorf("Expected %v to be unowned, but found %v", prov, nation))
+			}
+		}
+	}
+	if self.After.ForceDisbanded != nil {
+		found := map[godip.Province]bool{}
+		for prov := range s.ForceDisbands() {
+			found[prov.Super()] = true
+		}
+		expected := map[godip.Province]bool{}
+		for prov, unit := range self.After.ForceDisbanded {
+			expected[prov.Super()] = true
+			if !found[prov.Super()] {
+				result = append(result, fmt.Errorf("Expected %v in %v to be force disbanded, but it wasn't", unit, prov))
+			}
+		}
+		for prov := range found {
+			if !expected[prov] {
+				result = append(result, fmt.Errorf("Expected nothing to be force disbanded in %v, but found a force disband", prov))
+			}
+		}
+	}
+	for prov, order := range self.Before.Orders {
+		if order.Result == "" {
+			continue
+		}
+		if err, ok := s.Resolutions()[prov]; !ok {
+			result = append(result, fmt.Errorf("Expected %v to resolve to %v, but it wasn't adjudicated", order.Order, order.Result))
+		} else if !resultMatches(order.Result, err) {
+			result = append(result, fmt.Errorf("Expected %v to resolve to %v, but found %v", order.Order, order.Result, err))
+		}
+	}
+	return result
+}
+
+/*
+CheckUnordered returns the differences between the expected resolutions of the orders of the pair that s doesn't have,
+and the errors found when validating them against s, which is expected to be the pre state before Next has been called.
+
+It is meant for harnesses that leave out orders given to the units or supply centers of other nations, since a state
+only knows the nation of an order from the province it is given to. The checked expectations are cleared, so that
+Check doesn't expect those orders to be adjudicated.
+*/
+func (self *StatePair) CheckUnordered(s *state.State) (result []error) {
+	for prov, order := range self.Before.Orders {
+		if order.Result == "" {
+			continue
+		}
+		if _, _, ok := s.Order(prov); ok {
+			continue
+		}
+		if _, err := order.Order.Validate(s); !resultMatches(order.Result, err) {
+			result = append(result, fmt.Errorf("Expected %v to be rejected with %v, but found %v", order, order.Result, err))
+		}
+		order.Result = ""
+		self.Before.Orders[prov] = order
+	}
+	return result
+}
@@ -13,29 +13,33 @@ import (
 
 var clearCommentReg = regexp.MustCompile("(?m)^\\s*([^#\n\t]+?)\\s*(#.*)?$")
 
-var variantReg = regexp.MustCompile("^VARIANT_ALL\\s+(\\S*)\\s*$")
+var variantReg = regexp.MustCompile("^VARIANT_ALL\\s+(.*?)\\s*$")
 var caseReg = regexp.MustCompile("^CASE\\s+(.*)$")
 
 var prestateSetPhaseReg = regexp.MustCompile("^PRESTATE_SETPHASE\\s+(\\S+)\\s+(\\d+),\\s+(\\S+)\\s*$")
 
-var stateReg = regexp.MustCompile("^([^:\\s]+):?\\s+(\\S+)\\s+(\\S+)\\s*$")
+var stateReg = regexp.MustCompile("^([^:]+?):?\\s+(\\S+)\\s+(\\S+)\\s*$")
 
 var ordersReg = regexp.MustCompile("^([^:]+):\\s+(.*)$")
 
 var preOrderReg = regexp.MustCompile("^(SUCCESS|FAILURE):\\s+([^:]+):\\s+(.*)$")
 
+var orderResultReg = regexp.MustCompile("^(.*?)\\s+RESULT\\s+(\\S+)$")
+
 const (
-	prestate                   = "PRESTATE"
-	orders                     = "ORDERS"
-	poststateSame              = "POSTSTATE_SAME"
-	end                        = "END"
-	poststate                  = "POSTSTATE"
-	poststateDislodged         = "POSTSTATE_DISLODGED"
-	prestateSupplycenterOwners = "PRESTATE_SUPPLYCENTER_OWNERS"
-	prestateDislodged          = "PRESTATE_DISLODGED"
-	prestateResults            = "PRESTATE_RESULTS"
-	success                    = "SUCCESS"
-	failure                    = "FAILURE"
+	prestate                    = "PRESTATE"
+	orders                      = "ORDERS"
+	poststateSame               = "POSTSTATE_SAME"
+	end                         = "END"
+	poststate                   = "POSTSTATE"
+	poststateDislodged          = "POSTSTATE_DISLODGED"
+	prestateSupplycenterOwners  = "PRESTATE_SUPPLYCENTER_OWNERS"
+	prestateDislodged           = "PRESTATE_DISLODGED"
+	prestateResults             = "PRESTATE_RESULTS"
+	poststateSupplycenterOwners = "POSTSTATE_SUPPLYCENTER_OWNERS"
+	poststateForceDisbanded     = "POSTSTATE_FORCE_DISBANDED"
+	success                     = "SUCCESS"
+	failure                     = "FAILURE"
 )
 
 func newState() *State {
@@ -52,6 +56,10 @@ func newState() *State {
 type NationalizedOrder struct {
 	Order  godip.Adjudicator
 	Nation godip.Nation
+	// Result is the expected resolution of the order, from a "RESULT" suffix like "A par-bur RESULT ErrBounce", or
+	// empty if none was given. "OK" means success, and an error without ":" matches all errors of that kind, e.g.
+	// "ErrBounce" matches "ErrBounce:mun".
+	Result string
 }
 
 func (self NationalizedOrder) String() string {
@@ -59,13 +67,18 @@ func (self NationalizedOrder) String() string {
 }
 
 type State struct {
+	// SCs are the supply center owners. In the post state they are nil unless the case has a
+	// POSTSTATE_SUPPLYCENTER_OWNERS section.
 	SCs              map[godip.Province]godip.Nation
 	Units            map[godip.Province]godip.Unit
 	Dislodgeds       map[godip.Province]godip.Unit
 	Orders           map[godip.Province]NationalizedOrder
 	FailedOrders     map[godip.Province]NationalizedOrder
 	SuccessfulOrders map[godip.Province]NationalizedOrder
-	Phase            godip.Phase
+	// ForceDisbanded are the units disbanded without being ordered to, e.g. in civil disorder or because they had no
+	// retreat. Only used in the post state, and nil unless the case has a POSTSTATE_FORCE_DISBANDED section.
+	ForceDisbanded map[godip.Province]godip.Unit
+	Phase          godip.Phase
 }
 
 func (self *State) copyFrom(o *State) {
@@ -75,16 +88,20 @@ func (self *State) copyFrom(o *State) {
 	for prov, dislodged := range o.Dislodgeds {
 		self.Dislodgeds[prov] = dislodged
 	}
-	for prov, nation := range o.SCs {
-		self.SCs[prov] = nation
+	if self.SCs != nil {
+		for prov, nation := range o.SCs {
+			self.SCs[prov] = nation
+		}
 	}
 }
 
 func newStatePair() *StatePair {
-	return &StatePair{
+	result := &StatePair{
 		Before: newState(),
 		After:  newState(),
 	}
+	result.After.SCs = nil
+	return result
 }
 
 type StatePair struct {
@@ -109,6 +126,7 @@ type UnitTypeParser func(typ string) (godip.UnitType, error)
 
 type ProvinceParser func(prov string) (godip.Province, error)
 
+// Parser parses DATC files. If Variant is set, files with a VARIANT_ALL line for another variant are rejected.
 type Parser struct {
 	Variant        string
 	OrderParser    OrderParser
@@ -128,6 +146,8 @@ const (
 	inPrestateSupplycenterOwners
 	inPrestateDislodged
 	inPrestateResults
+	inPoststateSupplycenterOwners
+	inPoststateForceDisbanded
 )
 
 func (self Parser) Parse(r io.Reader, handler StatePairHandler) (err error) {
@@ -142,14 +162,16 @@ func (self Parser) Parse(r io.Reader, handler StatePairHandler) (err error) {
 			switch state {
 			case waiting:
 				if match = variantReg.FindStringSubmatch(line); match != nil {
-					if match[1] != self.Variant {
-						err = fmt.Errorf("%+v only supports DATC files for %v variant", self, self.Variant)
+					if self.Variant != "" && match[1] != self.Variant {
+						err = fmt.Errorf("Parser for %v can't parse DATC files for %v", self.Variant, match[1])
+						return
 					}
 				} else if match = caseReg.FindStringSubmatch(line); match != nil {
 					state = inCase
 					statePair.Case = match[1]
 				} else {
 					err = fmt.Errorf("Unrecognized line for state waiting: %#v", line)
+					return
 				}
 			case inPrestateSupplycenterOwners:
 				if match = stateReg.FindStringSubmatch(line); match != nil {
@@ -240,6 +262,12 @@ func (self Parser) Parse(r io.Reader, handler StatePairHandler) (err error) {
 					state = waiting
 				} else if line == poststateDislodged {
 					state = inPoststateDislodged
+				} else if line == poststateSupplycenterOwners {
+					statePair.After.SCs = map[godip.Province]godip.Nation{}
+					state = inPoststateSupplycenterOwners
+				} else if line == poststateForceDisbanded {
+					statePair.After.ForceDisbanded = map[godip.Province]godip.Unit{}
+					state = inPoststateForceDisbanded
 				} else {
 					err = fmt.Errorf("Unrecognized line for state inPoststate: %#v", line)
 					return
@@ -321,15 +349,77 @@ func (self Parser) Parse(r io.Reader, handler StatePairHandler) (err error) {
 					handler(statePair)
 					statePair = newStatePair()
 					state = waiting
+				} else if line == poststateSupplycenterOwners {
+					statePair.After.SCs = map[godip.Province]godip.Nation{}
+					state = inPoststateSupplycenterOwners
+				} else if line == poststateForceDisbanded {
+					statePair.After.ForceDisbanded = map[godip.Province]godip.Unit{}
+					state = inPoststateForceDisbanded
 				} else {
 					err = fmt.Errorf("Unrecognized line for state inPoststateDislodged: %#v", line)
 					return
 				}
+			case inPoststateSupplycenterOwners:
+				if match = stateReg.FindStringSubmatch(line); match != nil {
+					var owner godip.Nation
+					if owner, err = self.NationParser(match[1]); err != nil {
+						return
+					}
+					var prov godip.Province
+					if prov, err = self.ProvinceParser(match[3]); err != nil {
+						return
+					}
+					statePair.After.SCs[prov] = owner
+				} else if line == poststateForceDisbanded {
+					statePair.After.ForceDisbanded = map[godip.Province]godip.Unit{}
+					state = inPoststateForceDisbanded
+				} else if line == end {
+					handler(statePair)
+					statePair = newStatePair()
+					state = waiting
+				} else {
+					err = fmt.Errorf("Unrecognized line for state inPoststateSupplycenterOwners: %#v", line)
+					return
+				}
+			case inPoststateForceDisbanded:
+				if match = stateReg.FindStringSubmatch(line); match != nil {
+					var prov godip.Province
+					if prov, err = self.ProvinceParser(match[3]); err != nil {
+						return
+					}
+					var unit godip.UnitType
+					if unit, err = self.UnitTypeParser(match[2]); err != nil {
+						return
+					}
+					var nation godip.Nation
+					if nation, err = self.NationParser(match[1]); err != nil {
+						return
+					}
+					statePair.After.ForceDisbanded[prov] = godip.Unit{
+						Type:   unit,
+						Nation: nation,
+					}
+				} else if line == poststateSupplycenterOwners {
+					statePair.After.SCs = map[godip.Province]godip.Nation{}
+					state = inPoststateSupplycenterOwners
+				} else if line == end {
+					handler(statePair)
+					statePair = newStatePair()
+					state = waiting
+				} else {
+					err = fmt.Errorf("Unrecognized line for state inPoststateForceDisbanded: %#v", line)
+					return
+				}
 			case inOrders:
 				if match = ordersReg.FindStringSubmatch(line); match != nil {
+					text := match[2]
+					result := ""
+					if resultMatch := orderResultReg.FindStringSubmatch(text); resultMatch != nil {
+						text, result = resultMatch[1], resultMatch[2]
+					}
 					var prov godip.Province
 					var order godip.Adjudicator
-					if prov, order, err = self.OrderParser(match[2]); err != nil {
+					if prov, order, err = self.OrderParser(text); err != nil {
 						return
 					}
 					var nation godip.Nation
@@ -339,11 +429,18 @@ func (self Parser) Parse(r io.Reader, handler StatePairHandler) (err error) {
 					statePair.Before.Orders[prov] = NationalizedOrder{
 						Order:  order,
 						Nation: nation,
+						Result: result,
 					}
 				} else if line == poststateSame {
 					statePair.copyBeforeToAfter()
 				} else if line == poststate {
 					state = inPoststate
+				} else if line == poststateSupplycenterOwners {
+					statePair.After.SCs = map[godip.Province]godip.Nation{}
+					state = inPoststateSupplycenterOwners
+				} else if line == poststateForceDisbanded {
+					statePair.After.ForceDisbanded = map[godip.Province]godip.Unit{}
+					state = inPoststateForceDisbanded
 				} else if line == end {
 					handler(statePair)
 					statePair = newStatePair()
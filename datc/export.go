@@ -0,0 +1,238 @@
+package datc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zond/godip"
+	godiporders "github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+)
+
+func unitTypeText(typ godip.UnitType) string {
+	if typ == "" {
+		return "A"
+	}
+	return string(typ)[:1]
+}
+
+func sortedProvinces(provs map[godip.Province]bool) []godip.Province {
+	result := make([]godip.Province, 0, len(provs))
+	for prov := range provs {
+		result = append(result, prov)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+type exporter struct {
+	w      io.Writer
+	before *state.State
+	err    error
+}
+
+func (self *exporter) printf(format string, args ...interface{}) {
+	if self.err == nil {
+		_, self.err = fmt.Fprintf(self.w, format, args...)
+	}
+}
+
+func (self *exporter) units(units map[godip.Province]godip.Unit) {
+	provs := map[godip.Province]bool{}
+	for prov := range units {
+		provs[prov] = true
+	}
+	for _, prov := range sortedProvinces(provs) {
+		self.printf("\t%v: %v %v\n", units[prov].Nation, unitTypeText(units[prov].Type), prov)
+	}
+}
+
+// unit returns the unit at prov in the pre state, looking for dislodged units first in retreat phases.
+func (self *exporter) unit(prov godip.Province) (godip.Unit, bool) {
+	if self.before.Phase().Type() == godip.Retreat {
+		if unit, _, found := self.before.Dislodged(prov); found {
+			return unit, true
+		}
+	}
+	unit, _, found := self.before.Unit(prov)
+	if !found {
+		unit, _, found = self.before.Dislodged(prov)
+	}
+	return unit, found
+}
+
+func (self *exporter) unitText(prov godip.Province) string {
+	unit, _ := self.unit(prov)
+	return fmt.Sprintf("%v %v", unitTypeText(unit.Type), prov)
+}
+
+// orderText returns order in the DATC notation, and the nation giving it.
+func (self *exporter) orderText(order godip.Order) (string, godip.Nation, error) {
+	targets := order.Targets()
+	src := targets[0]
+	unit, _ := self.unit(src)
+	switch order.Type() {
+	case godip.Move:
+		if order.Flags()[godip.ViaConvoy] {
+			return fmt.Sprintf("%v-%v via convoy", self.unitText(src), targets[1]), unit.Nation, nil
+		}
+		return fmt.Sprintf("%v-%v", self.unitText(src), targets[1]), unit.Nation, nil
+	case godip.Hold:
+		return fmt.Sprintf("%v H", self.unitText(src)), unit.Nation, nil
+	case godip.Support:
+		if len(targets) == 2 || targets[1] == targets[2] {
+			return fmt.Sprintf("%v S %v", self.unitText(src), self.unitText(targets[1])), unit.Nation, nil
+		}
+		return fmt.Sprintf("%v S %v-%v", self.unitText(src), self.unitText(targets[1]), targets[2]), unit.Nation, nil
+	case godip.Convoy:
+		return fmt.Sprintf("%v C %v-%v", self.unitText(src), self.unitText(targets[1]), targets[2]), unit.Nation, nil
+	case godip.Build:
+		owner, _, _ := self.before.SupplyCenter(src)
+		encoded, err := godiporders.Encode(order)
+		if err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("Build %v %v", unitTypeText(godip.UnitType(encoded.Bits[2])), src), owner, nil
+	case godip.Disband:
+		if self.before.Phase().Type() == godip.Adjustment {
+			return fmt.Sprintf("Remove %v", self.unitText(src)), unit.Nation, nil
+		}
+		return fmt.Sprintf("%v disband", self.unitText(src)), unit.Nation, nil
+	}
+	return "", "", fmt.Errorf("Can't export %v to DATC", order)
+}
+
+// resultText returns err as a RESULT expectation, or false if it can't be written as one.
+func resultText(err error) (string, bool) {
+	if err == nil {
+		return "OK", true
+	}
+	text := err.Error()
+	if strings.ContainsAny(text, " \t\n") {
+		text = strings.Split(text, ":")[0]
+	}
+	if text == "" || strings.ContainsAny(text, " \t\n") {
+		return "", false
+	}
+	return text, true
+}
+
+/*
+Export writes the adjudication of one phase as a DATC test case named name, in the format read by Parser.
+
+before is the state, including its orders, as it was before Next was called (e.g. a Clone taken just before), and after
+is the same state after Next. The resolutions of the orders are written as RESULT expectations (or as comments, when
+they can't be expressed as one), and the units, dislodged units, supply center owners and force disbanded units of
+after as the expected post state.
+
+Nations, unit types and provinces are written as godip names, e.g. "England: F stp/nc", so the DATC parsers of every
+variant can read them back.
+*/
+func Export(w io.Writer, name string, before, after *state.State) error {
+	e := &exporter{
+		w:      w,
+		before: before,
+	}
+	phase := before.Phase()
+	e.printf("CASE %v\n", name)
+	e.printf("PRESTATE_SETPHASE %v %v, %v\n", phase.Season(), phase.Year(), phase.Type())
+
+	units, supplyCenters, dislodgeds, dislodgers, bounces, _ := before.Dump()
+	if len(supplyCenters) > 0 {
+		e.printf("PRESTATE_SUPPLYCENTER_OWNERS\n")
+		provs := map[godip.Province]bool{}
+		for prov := range supplyCenters {
+			provs[prov] = true
+		}
+		for _, prov := range sortedProvinces(provs) {
+			e.printf("\t%v: %v\n", supplyCenters[prov], e.unitText(prov))
+		}
+	}
+	e.printf("PRESTATE\n")
+	e.units(units)
+	if len(dislodgeds) > 0 {
+		e.printf("PRESTATE_DISLODGED\n")
+		e.units(dislodgeds)
+	}
+
+	if phase.Type() == godip.Retreat && (len(dislodgers) > 0 || len(bounces) > 0) {
+		e.printf("PRESTATE_RESULTS\n")
+		provs := map[godip.Province]bool{}
+		for prov := range dislodgers {
+			provs[prov] = true
+		}
+		for _, attacker := range sortedProvinces(provs) {
+			victim := dislodgers[attacker]
+			if unit, _, found := before.Unit(victim); found {
+				e.printf("\tSUCCESS: %v: %v %v-%v\n", unit.Nation, unitTypeText(unit.Type), attacker, victim)
+			}
+		}
+		provs = map[godip.Province]bool{}
+		for dst := range bounces {
+			provs[dst] = true
+		}
+		for _, dst := range sortedProvinces(provs) {
+			for _, src := range sortedProvinces(bounces[dst]) {
+				if unit, found := e.unit(src); found {
+					e.printf("\tFAILURE: %v: %v %v-%v\n", unit.Nation, unitTypeText(unit.Type), src, dst)
+				}
+			}
+		}
+	}
+
+	e.printf("ORDERS\n")
+	orders := before.Orders()
+	provs := map[godip.Province]bool{}
+	for prov := range orders {
+		provs[prov] = true
+	}
+	for _, prov := range sortedProvinces(provs) {
+		text, nation, err := e.orderText(orders[prov])
+		if err != nil {
+			return err
+		}
+		if err, found := after.Resolutions()[prov]; !found {
+			e.printf("\t%v: %v # NOT ADJUDICATED\n", nation, text)
+		} else if result, ok := resultText(err); ok {
+			e.printf("\t%v: %v RESULT %v\n", nation, text, result)
+		} else {
+			e.printf("\t%v: %v # FAILURE: %v\n", nation, text, strings.ReplaceAll(err.Error(), "\n", " "))
+		}
+	}
+
+	e.printf("POSTSTATE\n")
+	e.units(after.Units())
+	if len(after.Dislodgeds()) > 0 {
+		e.printf("POSTSTATE_DISLODGED\n")
+		e.units(after.Dislodgeds())
+	}
+	if len(after.SupplyCenters()) > 0 {
+		e.printf("POSTSTATE_SUPPLYCENTER_OWNERS\n")
+		provs = map[godip.Province]bool{}
+		for prov := range after.SupplyCenters() {
+			provs[prov] = true
+		}
+		for _, prov := range sortedProvinces(provs) {
+			e.printf("\t%v: %v\n", after.SupplyCenters()[prov], e.unitText(prov))
+		}
+	}
+	// Always written, even if empty, so that missing force disbands are verified as well.
+	e.printf("POSTSTATE_FORCE_DISBANDED\n")
+	provs = map[godip.Province]bool{}
+	for prov := range after.ForceDisbands() {
+		if !before.ForceDisbands()[prov] {
+			provs[prov] = true
+		}
+	}
+	for _, prov := range sortedProvinces(provs) {
+		if unit, found := e.unit(prov); found {
+			e.printf("\t%v: %v\n", unit.Nation, e.unitText(prov))
+		}
+	}
+	e.printf("END\n")
+	return e.err
+}
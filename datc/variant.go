@@ -0,0 +1,86 @@
+package datc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/notation"
+	"github.com/zond/godip/variants/common"
+)
+
+/*
+VariantParser returns a parser for DATC files of variant, e.g. with "VARIANT_ALL Classical".
+
+Provinces are the provinces of the variant graph, nations the nations of the variant (and "Neutral" for neutral units),
+unit types the full names or first letters of the variant unit types, and orders are parsed like the notation package
+does, using the order parser of the variant.
+*/
+func VariantParser(variant common.Variant) Parser {
+	provinces := map[string]godip.Province{}
+	for _, prov := range variant.Graph().Provinces() {
+		provinces[strings.ToLower(string(prov))] = prov
+	}
+	nations := map[string]godip.Nation{
+		strings.ToLower(string(godip.Neutral)): godip.Neutral,
+	}
+	for _, nation := range variant.Nations {
+		nations[strings.ToLower(string(nation))] = nation
+	}
+	unitTypes := map[string]godip.UnitType{}
+	for _, typ := range variant.UnitTypes {
+		unitTypes[strings.ToLower(string(typ))] = typ
+		unitTypes[strings.ToLower(string(typ)[:1])] = typ
+	}
+	seasons := map[string]godip.Season{}
+	for _, season := range variant.Seasons {
+		seasons[strings.ToLower(string(season))] = season
+	}
+	phaseTypes := map[string]godip.PhaseType{}
+	for _, typ := range variant.PhaseTypes {
+		phaseTypes[strings.ToLower(string(typ))] = typ
+	}
+	orderNotation := notation.New(variant)
+	return Parser{
+		Variant: variant.Name,
+		OrderParser: func(text string) (godip.Province, godip.Adjudicator, error) {
+			order, err := orderNotation.Parse(text)
+			if err != nil {
+				return "", nil, err
+			}
+			return order.Targets()[0], order, nil
+		},
+		PhaseParser: func(season string, year int, typ string) (godip.Phase, error) {
+			phaseSeason, ok := seasons[strings.ToLower(season)]
+			if !ok {
+				return nil, fmt.Errorf("Unknown season %#v", season)
+			}
+			phaseType, ok := phaseTypes[strings.ToLower(typ)]
+			if !ok {
+				return nil, fmt.Errorf("Unknown phase type %#v", typ)
+			}
+			return variant.Phase(year, phaseSeason, phaseType), nil
+		},
+		NationParser: func(nation string) (godip.Nation, error) {
+			result, ok := nations[strings.ToLower(nation)]
+			if !ok {
+				return "", fmt.Errorf("Unknown nationality: %#v", nation)
+			}
+			return result, nil
+		},
+		UnitTypeParser: func(typ string) (godip.UnitType, error) {
+			result, ok := unitTypes[strings.ToLower(typ)]
+			if !ok {
+				return "", fmt.Errorf("Unknown unit type: %#v", typ)
+			}
+			return result, nil
+		},
+		ProvinceParser: func(prov string) (godip.Province, error) {
+			result, ok := provinces[strings.ToLower(prov)]
+			if !ok {
+				return "", fmt.Errorf("Unknown province %#v", prov)
+			}
+			return result, nil
+		},
+	}
+}
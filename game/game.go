@@ -0,0 +1,144 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+// Phase is one phase of a game.
+type Phase struct {
+	// State is the position at the start of the phase, without orders.
+	State *state.State
+	// Orders are the orders given during the phase.
+	Orders map[godip.Province]godip.Adjudicator
+	// Resolutions are the resolutions of the orders, nil until the phase has been adjudicated.
+	Resolutions map[godip.Province]error
+	// Explanations describe the resolutions, nil until the phase has been adjudicated.
+	Explanations map[godip.Province]*godip.Explanation
+}
+
+// Adjudicated returns whether the phase has been adjudicated, i.e. whether it isn't the current phase of the game.
+func (self *Phase) Adjudicated() bool {
+	return self.Resolutions != nil
+}
+
+// Game is the history of a game of a variant, phase by phase.
+type Game struct {
+	Variant common.Variant
+	Phases  []*Phase
+}
+
+// New returns a game at the start of variant.
+func New(variant common.Variant) (*Game, error) {
+	s, err := variant.Start()
+	if err != nil {
+		return nil, err
+	}
+	return FromState(variant, s), nil
+}
+
+// FromState returns a game of variant starting at (a copy of) s.
+func FromState(variant common.Variant, s *state.State) *Game {
+	start := s.Clone()
+	start.SetOrders(nil)
+	return &Game{
+		Variant: variant,
+		Phases: []*Phase{
+			{
+				State:  start,
+				Orders: map[godip.Province]godip.Adjudicator{},
+			},
+		},
+	}
+}
+
+// Current returns the phase that hasn't been adjudicated yet.
+func (self *Game) Current() *Phase {
+	return self.Phases[len(self.Phases)-1]
+}
+
+// State returns a copy of the position at the start of the phase with the provided index, with its orders set, for
+// exploring the game as it was at that phase.
+func (self *Game) State(index int) (*state.State, error) {
+	if index < 0 || index >= len(self.Phases) {
+		return nil, fmt.Errorf("No phase %v in a game with %v phases", index, len(self.Phases))
+	}
+	result := self.Phases[index].State.Clone()
+	result.SetOrders(copyOrders(self.Phases[index].Orders))
+	return result, nil
+}
+
+// Next adjudicates the current phase using orders, and makes the following phase current.
+func (self *Game) Next(orders map[godip.Province]godip.Adjudicator) error {
+	current := self.Current()
+	current.Orders = orders
+	next, err := self.adjudicate(current)
+	if err != nil {
+		return err
+	}
+	self.Phases = append(self.Phases, next)
+	return nil
+}
+
+// SetOrders replaces the orders of the phase with the provided index, and re-adjudicates it and all following phases
+// using their recorded orders.
+func (self *Game) SetOrders(index int, orders map[godip.Province]godip.Adjudicator) error {
+	if index < 0 || index >= len(self.Phases) {
+		return fmt.Errorf("No phase %v in a game with %v phases", index, len(self.Phases))
+	}
+	self.Phases[index].Orders = orders
+	return self.Replay(index)
+}
+
+// Replay re-adjudicates all adjudicated phases from the phase with the provided index using their recorded orders,
+// replacing the positions and resolutions of the following phases.
+func (self *Game) Replay(from int) error {
+	if from < 0 || from >= len(self.Phases) {
+		return fmt.Errorf("No phase %v in a game with %v phases", from, len(self.Phases))
+	}
+	for index := from; index < len(self.Phases)-1; index++ {
+		next, err := self.adjudicate(self.Phases[index])
+		if err != nil {
+			return err
+		}
+		next.Orders = self.Phases[index+1].Orders
+		self.Phases[index+1] = next
+	}
+	return nil
+}
+
+func (self *Game) adjudicate(phase *Phase) (*Phase, error) {
+	s := phase.State.Clone()
+	s.SetOrders(copyOrders(phase.Orders))
+	if err := s.Next(); err != nil {
+		return nil, err
+	}
+	phase.Resolutions = s.Resolutions()
+	phase.Explanations = s.Explanations()
+	return &Phase{
+		State:  s,
+		Orders: map[godip.Province]godip.Adjudicator{},
+	}, nil
+}
+
+// copyOrders returns copies of orders, since adjudication refines the orders it is given, and the recorded orders of
+// a phase have to stay as they were given to be replayed.
+func copyOrders(orders map[godip.Province]godip.Adjudicator) map[godip.Province]godip.Adjudicator {
+	result := make(map[godip.Province]godip.Adjudicator, len(orders))
+	for prov, order := range orders {
+		result[prov] = order.Clone()
+	}
+	return result
+}
+
+// Result returns how the game stands at the current phase, given the earlier phases.
+func (self *Game) Result() common.GameResult {
+	previous := []*state.State{}
+	for _, phase := range self.Phases[:len(self.Phases)-1] {
+		previous = append(previous, phase.State)
+	}
+	return self.Variant.Result(self.Current().State, previous...)
+}
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/variants/classical"
+)
+
+func TestReplay(t *testing.T) {
+	g, err := New(classical.ClassicalVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+		"mun": orders.Move("mun", "bur"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "pic"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Phases) != 4 {
+		t.Fatalf("Wanted 4 phases, got %v", len(g.Phases))
+	}
+	if _, ok := g.Phases[0].Resolutions["par"].(godip.ErrBounce); !ok {
+		t.Errorf("Wanted par to bounce in the first phase, got %v", g.Phases[0].Resolutions["par"])
+	}
+	if g.Current().Adjudicated() || !g.Phases[2].Adjudicated() {
+		t.Errorf("Wanted only the last phase to be unadjudicated")
+	}
+	if unit, _, ok := g.Current().State.Unit("pic"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted France in pic, got %v, %v", unit, ok)
+	}
+
+	if err := g.SetOrders(0, map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Phases) != 4 {
+		t.Fatalf("Wanted 4 phases after correcting orders, got %v", len(g.Phases))
+	}
+	if found := g.Phases[0].Resolutions["par"]; found != nil {
+		t.Errorf("Wanted par to succeed after correcting orders, got %v", found)
+	}
+	if unit, _, ok := g.Current().State.Unit("bur"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted France to stay in bur, got %v, %v", unit, ok)
+	}
+	if _, _, ok := g.Current().State.Unit("pic"); ok {
+		t.Errorf("Wanted pic to be empty, since the recorded move from par is invalid after the correction")
+	}
+
+	s, err := g.State(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit, _, ok := s.Unit("par"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted France in par at the start, got %v, %v", unit, ok)
+	}
+	if order, _, ok := s.Order("par"); !ok || order.Targets()[1] != "bur" {
+		t.Errorf("Wanted the corrected order for par, got %v, %v", order, ok)
+	}
+	s.Next()
+	if unit, _, ok := g.Phases[0].State.Unit("par"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted adjudicating a copy of a phase to leave the game untouched, got %v, %v", unit, ok)
+	}
+	if _, err := g.State(4); err == nil {
+		t.Errorf("Wanted an error for a missing phase")
+	}
+}
+
+func TestReplayFixesLaterOrders(t *testing.T) {
+	g, err := New(classical.ClassicalVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Next(map[godip.Province]godip.Adjudicator{
+		"bur": orders.Move("bur", "pic"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Phases[2].Resolutions["bur"]; err == nil {
+		t.Errorf("Wanted bur-pic to fail without a unit in bur")
+	}
+	if err := g.SetOrders(0, map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if order := g.Phases[2].Orders["bur"]; order.Targets()[0] != "bur" || order.Targets()[1] != "pic" {
+		t.Errorf("Wanted the recorded order to stay bur-pic, got %v", order.Targets())
+	}
+	if err := g.Phases[2].Resolutions["bur"]; err != nil {
+		t.Errorf("Wanted bur-pic to succeed after moving par to bur, got %v", err)
+	}
+	if unit, _, ok := g.Current().State.Unit("pic"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted France in pic, got %v, %v", unit, ok)
+	}
+}
+
+func TestRules(t *testing.T) {
+	s := classical.Blank(classical.NewPhase(1901, godip.Spring, godip.Movement))
+	s.SetUnit("gas", godip.Unit{Type: godip.Fleet, Nation: godip.France})
+	rules := godip.Rules{BackupRule: "AllHold", StrictCoasts: true}
+	g := FromState(classical.ClassicalVariant, s.SetRules(rules))
+	if err := g.Next(map[godip.Province]godip.Adjudicator{
+		"gas": orders.Move("gas", "spa"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Phases[0].Resolutions["gas"]; err != godip.ErrIllegalMove {
+		t.Errorf("Wanted the strict coast rule to make gas-spa illegal, got %v", err)
+	}
+	if got := g.Current().State.Rules(); got != rules {
+		t.Errorf("Wanted %+v in the next phase, got %+v", rules, got)
+	}
+}
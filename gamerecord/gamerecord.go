@@ -0,0 +1,478 @@
+/*
+Package gamerecord reads and writes the game record format of the games/game_*.txt files of the variants, e.g.
+
+	PHASE 1901 Spring Movement
+	POSITIONS
+		England: fleet lon
+		England: supply lon
+	ORDERS
+		lon move nth
+
+Each phase lists the units, dislodged units and supply center owners at its start, and the orders given during it.
+*/
+package gamerecord
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/game"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+var (
+	phaseReg = regexp.MustCompile("^PHASE (\\d+) (\\S+) (\\S+)$")
+	posReg   = regexp.MustCompile("^(.+): (fleet|army|supply|fleet/dislodged|army/dislodged) (\\S+)$")
+
+	moveReg          = regexp.MustCompile("^(\\S+)\\s+move\\s+(\\S+)$")
+	moveViaConvoyReg = regexp.MustCompile("^(\\S+)\\s+move\\s+(\\S+)\\s+via\\s+convoy$")
+	supportMoveReg   = regexp.MustCompile("^(\\S+)\\s+support\\s+(\\S+)\\s+move\\s+(\\S+)$")
+	supportHoldReg   = regexp.MustCompile("^(\\S+)\\s+support\\s+(\\S+)$")
+	holdReg          = regexp.MustCompile("^(\\S+)\\s+hold$")
+	convoyReg        = regexp.MustCompile("^(\\S+)\\s+convoy\\s+(\\S+)\\s+move\\s+(\\S+)$")
+	buildReg         = regexp.MustCompile("^build\\s+(Army|Fleet)\\s+(\\S+)$")
+	buildAnywhereReg = regexp.MustCompile("^build\\s+anywhere\\s+(Army|Fleet)\\s+(\\S+)$")
+	removeReg        = regexp.MustCompile("^remove\\s+(\\S+)$")
+	disbandReg       = regexp.MustCompile("^(\\S+)\\s+disband$")
+)
+
+const (
+	positionsTag = "POSITIONS"
+	ordersTag    = "ORDERS"
+)
+
+const (
+	inNothing = iota
+	inPhase
+	inPositions
+	inOrders
+)
+
+// Record is a game, phase by phase.
+type Record struct {
+	Phases []*Phase
+}
+
+// Phase is one phase of a record.
+type Phase struct {
+	Year   int
+	Season godip.Season
+	Type   godip.PhaseType
+	// Units, Dislodgeds and SupplyCenters are the positions at the start of the phase, all nil if the phase has no
+	// POSITIONS section.
+	Units         map[godip.Province]godip.Unit
+	Dislodgeds    map[godip.Province]godip.Unit
+	SupplyCenters map[godip.Province]godip.Nation
+	// Orders are the orders given during the phase, nil if the phase has no ORDERS section.
+	Orders map[godip.Province]godip.Adjudicator
+}
+
+func (self *Phase) String() string {
+	return fmt.Sprintf("%v %v %v", self.Year, self.Season, self.Type)
+}
+
+// parseOrder returns the province and order of an order line, or false if it isn't one.
+func parseOrder(line string) (godip.Province, godip.Adjudicator, bool) {
+	var match []string
+	if match = moveReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Move(godip.Province(match[1]), godip.Province(match[2])), true
+	} else if match = moveViaConvoyReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Move(godip.Province(match[1]), godip.Province(match[2])).ViaConvoy(), true
+	} else if match = supportMoveReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.SupportMove(godip.Province(match[1]), godip.Province(match[2]), godip.Province(match[3])), true
+	} else if match = supportHoldReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.SupportHold(godip.Province(match[1]), godip.Province(match[2])), true
+	} else if match = holdReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Hold(godip.Province(match[1])), true
+	} else if match = convoyReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Convoy(godip.Province(match[1]), godip.Province(match[2]), godip.Province(match[3])), true
+	} else if match = buildReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[2]), orders.Build(godip.Province(match[2]), godip.UnitType(match[1]), time.Now()), true
+	} else if match = buildAnywhereReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[2]), orders.BuildAnywhere(godip.Province(match[2]), godip.UnitType(match[1]), time.Now()), true
+	} else if match = removeReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Disband(godip.Province(match[1]), time.Now()), true
+	} else if match = disbandReg.FindStringSubmatch(line); match != nil {
+		return godip.Province(match[1]), orders.Disband(godip.Province(match[1]), time.Now()), true
+	}
+	return "", nil, false
+}
+
+func (self *Phase) addPosition(match []string) {
+	nation, prov := godip.Nation(match[1]), godip.Province(match[3])
+	switch match[2] {
+	case "army":
+		self.Units[prov] = godip.Unit{Type: godip.Army, Nation: nation}
+	case "fleet":
+		self.Units[prov] = godip.Unit{Type: godip.Fleet, Nation: nation}
+	case "army/dislodged":
+		self.Dislodgeds[prov] = godip.Unit{Type: godip.Army, Nation: nation}
+	case "fleet/dislodged":
+		self.Dislodgeds[prov] = godip.Unit{Type: godip.Fleet, Nation: nation}
+	case "supply":
+		self.SupplyCenters[prov] = nation
+	}
+}
+
+// Read returns the record read from r. Empty lines are ignored.
+func Read(r io.Reader) (*Record, error) {
+	result := &Record{}
+	var phase *Phase
+	state := inNothing
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+		if match := phaseReg.FindStringSubmatch(line); match != nil {
+			year, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, err
+			}
+			phase = &Phase{
+				Year:   year,
+				Season: godip.Season(match[2]),
+				Type:   godip.PhaseType(match[3]),
+			}
+			result.Phases = append(result.Phases, phase)
+			state = inPhase
+			continue
+		}
+		switch state {
+		case inPhase:
+			if line == positionsTag {
+				phase.Units = map[godip.Province]godip.Unit{}
+				phase.Dislodgeds = map[godip.Province]godip.Unit{}
+				phase.SupplyCenters = map[godip.Province]godip.Nation{}
+				state = inPositions
+			} else if line == ordersTag {
+				phase.Orders = map[godip.Province]godip.Adjudicator{}
+				state = inOrders
+			} else {
+				return nil, fmt.Errorf("Unrecognized line for state inPhase: %#v", line)
+			}
+		case inPositions:
+			if match := posReg.FindStringSubmatch(line); match != nil {
+				phase.addPosition(match)
+			} else if line == ordersTag {
+				phase.Orders = map[godip.Province]godip.Adjudicator{}
+				state = inOrders
+			} else {
+				return nil, fmt.Errorf("Unrecognized line for state inPositions: %#v", line)
+			}
+		case inOrders:
+			if prov, order, ok := parseOrder(line); ok {
+				phase.Orders[prov] = order
+			} else {
+				return nil, fmt.Errorf("Unrecognized line for state inOrders: %#v", line)
+			}
+		default:
+			return nil, fmt.Errorf("Unrecognized line for state inNothing: %#v", line)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadFile returns the record in the file with the provided name.
+func ReadFile(name string) (*Record, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return result, nil
+}
+
+func sortedProvinces(provs map[godip.Province]bool) []godip.Province {
+	result := make([]godip.Province, 0, len(provs))
+	for prov := range provs {
+		result = append(result, prov)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+/*
+orderText returns order, given for prov, as an order line, with disbands written as removals in adjustment phases.
+
+prov is used as the source of the order, since validating orders replaces their source with the coast of the unit, or
+with nothing if there is no unit.
+*/
+func orderText(prov godip.Province, order godip.Order, phaseType godip.PhaseType) (string, error) {
+	targets := order.Targets()
+	switch order.Type() {
+	case godip.Move:
+		if order.Flags()[godip.ViaConvoy] {
+			return fmt.Sprintf("%v move %v via convoy", prov, targets[1]), nil
+		}
+		return fmt.Sprintf("%v move %v", prov, targets[1]), nil
+	case godip.Hold:
+		return fmt.Sprintf("%v hold", prov), nil
+	case godip.Support:
+		if len(targets) == 2 || targets[1] == targets[2] {
+			return fmt.Sprintf("%v support %v", prov, targets[1]), nil
+		}
+		return fmt.Sprintf("%v support %v move %v", prov, targets[1], targets[2]), nil
+	case godip.Convoy:
+		return fmt.Sprintf("%v convoy %v move %v", prov, targets[1], targets[2]), nil
+	case godip.Build:
+		encoded, err := orders.Encode(order)
+		if err != nil {
+			return "", err
+		}
+		if order.Flags()[godip.Anywhere] || order.Flags()[godip.AnyHomeCenter] {
+			return fmt.Sprintf("build anywhere %v %v", encoded.Bits[2], prov), nil
+		}
+		return fmt.Sprintf("build %v %v", encoded.Bits[2], prov), nil
+	case godip.Disband:
+		if phaseType == godip.Adjustment {
+			return fmt.Sprintf("remove %v", prov), nil
+		}
+		return fmt.Sprintf("%v disband", prov), nil
+	}
+	return "", fmt.Errorf("Can't write %v to a game record", order)
+}
+
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (self *writer) units(nation godip.Nation, units map[godip.Province]godip.Unit, suffix string) {
+	provs := map[godip.Province]bool{}
+	for prov, unit := range units {
+		if unit.Nation == nation {
+			provs[prov] = true
+		}
+	}
+	for _, prov := range sortedProvinces(provs) {
+		self.printf("\t%v: %v%v %v\n", nation, strings.ToLower(string(units[prov].Type)), suffix, prov)
+	}
+}
+
+func (self *writer) printf(format string, args ...interface{}) {
+	if self.err == nil {
+		_, self.err = fmt.Fprintf(self.w, format, args...)
+	}
+}
+
+/*
+Write writes the record to w, in the format read by Read.
+
+The positions are written nation by nation, and the orders by province, so writing the same record twice gives the
+same output.
+*/
+func (self *Record) Write(w io.Writer) error {
+	out := &writer{w: w}
+	for _, phase := range self.Phases {
+		out.printf("PHASE %v %v %v\n", phase.Year, phase.Season, phase.Type)
+		if phase.Units != nil || phase.Dislodgeds != nil || phase.SupplyCenters != nil {
+			out.printf("%v\n", positionsTag)
+			nations := map[godip.Nation]bool{}
+			for _, unit := range phase.Units {
+				nations[unit.Nation] = true
+			}
+			for _, unit := range phase.Dislodgeds {
+				nations[unit.Nation] = true
+			}
+			for _, nation := range phase.SupplyCenters {
+				nations[nation] = true
+			}
+			sortedNations := make([]godip.Nation, 0, len(nations))
+			for nation := range nations {
+				sortedNations = append(sortedNations, nation)
+			}
+			sort.Slice(sortedNations, func(i, j int) bool {
+				return sortedNations[i] < sortedNations[j]
+			})
+			for _, nation := range sortedNations {
+				out.units(nation, phase.Units, "")
+				out.units(nation, phase.Dislodgeds, "/dislodged")
+				provs := map[godip.Province]bool{}
+				for prov, owner := range phase.SupplyCenters {
+					if owner == nation {
+						provs[prov] = true
+					}
+				}
+				for _, prov := range sortedProvinces(provs) {
+					out.printf("\t%v: supply %v\n", nation, prov)
+				}
+			}
+		}
+		if phase.Orders != nil {
+			out.printf("%v\n", ordersTag)
+			provs := map[godip.Province]bool{}
+			for prov := range phase.Orders {
+				provs[prov] = true
+			}
+			for _, prov := range sortedProvinces(provs) {
+				text, err := orderText(prov, phase.Orders[prov], phase.Type)
+				if err != nil {
+					return err
+				}
+				out.printf("\t%v\n", text)
+			}
+		}
+	}
+	return out.err
+}
+
+/*
+FromStates returns a record of states, which are expected to be the consecutive phases of a game with the orders given
+during each phase set, e.g. clones taken just before calling Next.
+
+Phases without orders get no ORDERS section.
+*/
+func FromStates(states []*state.State) *Record {
+	result := &Record{}
+	for _, s := range states {
+		phase := &Phase{
+			Year:          s.Phase().Year(),
+			Season:        s.Phase().Season(),
+			Type:          s.Phase().Type(),
+			Units:         map[godip.Province]godip.Unit{},
+			Dislodgeds:    map[godip.Province]godip.Unit{},
+			SupplyCenters: map[godip.Province]godip.Nation{},
+		}
+		for prov, unit := range s.Units() {
+			phase.Units[prov] = unit
+		}
+		for prov, unit := range s.Dislodgeds() {
+			phase.Dislodgeds[prov] = unit
+		}
+		for prov, nation := range s.SupplyCenters() {
+			phase.SupplyCenters[prov] = nation
+		}
+		if len(s.Orders()) > 0 {
+			phase.Orders = map[godip.Province]godip.Adjudicator{}
+			for prov, order := range s.Orders() {
+				phase.Orders[prov] = order
+			}
+		}
+		result.Phases = append(result.Phases, phase)
+	}
+	return result
+}
+
+// FromGame returns a record of all phases of g, including the current one.
+func FromGame(g *game.Game) (*Record, error) {
+	states := make([]*state.State, 0, len(g.Phases))
+	for index := range g.Phases {
+		s, err := g.State(index)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, s)
+	}
+	return FromStates(states), nil
+}
+
+func (self *Phase) matches(phase godip.Phase) bool {
+	return phase.Year() == self.Year && phase.Season() == self.Season && phase.Type() == self.Type
+}
+
+/*
+Advance adjudicates s until it reaches the phase, and returns a state created with blank for the phase, loaded with
+the positions of s. If s already is in the phase it is returned as is.
+
+Records don't have to contain every phase of a game, e.g. retreat phases without dislodged units are usually left out,
+which is why more than one phase might be adjudicated. Since no orders are given in the skipped phases, they are
+adjudicated using the default orders.
+*/
+func (self *Phase) Advance(s *state.State, blank func(godip.Phase) *state.State) (*state.State, error) {
+	if self.matches(s.Phase()) {
+		return s, nil
+	}
+	for !self.matches(s.Phase()) {
+		if s.Phase().Year() > self.Year {
+			return nil, fmt.Errorf("Wanted %v, but ended up with %v", self, s.Phase())
+		}
+		if err := s.Next(); err != nil {
+			return nil, err
+		}
+	}
+	result := blank(s.Phase()).SetLogger(s.Logger())
+	units, supplyCenters, dislodgeds, dislodgers, bounces, _ := s.Dump()
+	result.Load(units, supplyCenters, dislodgeds, dislodgers, bounces, map[godip.Province]godip.Adjudicator{})
+	return result, nil
+}
+
+// Check returns the differences between the positions of the phase and s. Phases without positions always match.
+func (self *Phase) Check(s *state.State) (result []error) {
+	if self.Units == nil && self.Dislodgeds == nil && self.SupplyCenters == nil {
+		return nil
+	}
+	for prov, nation := range self.SupplyCenters {
+		if found, _, ok := s.SupplyCenter(prov); !ok || found != nation {
+			result = append(result, fmt.Errorf("Expected %v to own SC in %v, but found %v, %v", nation, prov, found, ok))
+		}
+	}
+	for prov, unit := range self.Units {
+		if found, _, ok := s.Unit(prov); !ok || !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Expected to find %v %v in %v, but found %v, %v", unit.Nation, unit.Type, prov, found, ok))
+		}
+	}
+	for prov, unit := range self.Dislodgeds {
+		if found, _, ok := s.Dislodged(prov); !ok || !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Expected to find %v %v dislodged in %v, but found %v, %v", unit.Nation, unit.Type, prov, found, ok))
+		}
+	}
+	for prov, found := range s.SupplyCenters() {
+		if nation, ok := self.SupplyCenters[prov]; !ok || found != nation {
+			result = append(result, fmt.Errorf("Found %v in %v, expected %v, %v", found, prov, nation, ok))
+		}
+	}
+	for prov, found := range s.Units() {
+		if unit, ok := self.Units[prov]; !ok || !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Found %v in %v, expected %v, %v", found, prov, unit, ok))
+		}
+	}
+	for prov, found := range s.Dislodgeds() {
+		if unit, ok := self.Dislodgeds[prov]; !ok || !found.Equal(unit) {
+			result = append(result, fmt.Errorf("Found %v dislodged in %v, expected %v, %v", found, prov, unit, ok))
+		}
+	}
+	return result
+}
+
+/*
+States replays the record from the start of variant, and returns the state of every phase with the orders given in
+it set.
+
+The positions of the phases aren't verified, use Check for that.
+*/
+func (self *Record) States(variant common.Variant) ([]*state.State, error) {
+	s, err := variant.Start()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*state.State, 0, len(self.Phases))
+	for _, phase := range self.Phases {
+		if s, err = phase.Advance(s, variant.Blank); err != nil {
+			return nil, err
+		}
+		s.SetOrders(phase.Orders)
+		result = append(result, s.Clone())
+	}
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package gamerecord
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/game"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/variants/classical"
+)
+
+func TestRead(t *testing.T) {
+	record, err := Read(strings.NewReader(`PHASE 1901 Fall Retreat
+POSITIONS
+	England: fleet stp/nc
+	England: army/dislodged bel
+	France: supply bre
+ORDERS
+	bel move pic
+	stp/nc hold
+
+PHASE 1901 Fall Adjustment
+ORDERS
+	build Army lvp
+	build anywhere Fleet edi
+	remove stp
+PHASE 1902 Spring Movement
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Phases) != 3 {
+		t.Fatalf("Wanted 3 phases, got %v", len(record.Phases))
+	}
+	phase := record.Phases[0]
+	if phase.Year != 1901 || phase.Season != godip.Fall || phase.Type != godip.Retreat {
+		t.Errorf("Wanted Fall 1901 Retreat, got %v", phase)
+	}
+	if unit := phase.Units["stp/nc"]; unit.Type != godip.Fleet || unit.Nation != godip.England {
+		t.Errorf("Wanted an English fleet in stp/nc, got %v", unit)
+	}
+	if unit := phase.Dislodgeds["bel"]; unit.Type != godip.Army || unit.Nation != godip.England {
+		t.Errorf("Wanted a dislodged English army in bel, got %v", unit)
+	}
+	if nation := phase.SupplyCenters["bre"]; nation != godip.France {
+		t.Errorf("Wanted France to own bre, got %v", nation)
+	}
+	if order := phase.Orders["bel"]; order == nil || order.Type() != godip.Move || order.Targets()[1] != "pic" {
+		t.Errorf("Wanted bel to move to pic, got %v", order)
+	}
+	if phase = record.Phases[1]; phase.Units != nil || len(phase.Orders) != 3 {
+		t.Errorf("Wanted no positions and 3 orders, got %v and %v", phase.Units, phase.Orders)
+	}
+	if !phase.Orders["edi"].Flags()[godip.Anywhere] {
+		t.Errorf("Wanted a build anywhere in edi, got %v", phase.Orders["edi"])
+	}
+	if phase = record.Phases[2]; phase.Orders != nil {
+		t.Errorf("Wanted no orders in the last phase, got %v", phase.Orders)
+	}
+
+	if _, err := Read(strings.NewReader("PHASE 1901 Spring Movement\nORDERS\n\tpar dance\n")); err == nil {
+		t.Errorf("Wanted an error for an unknown order")
+	}
+	if _, err := Read(strings.NewReader("POSITIONS\n")); err == nil {
+		t.Errorf("Wanted an error for positions without a phase")
+	}
+}
+
+func TestWriteGames(t *testing.T) {
+	files, err := filepath.Glob("../variants/*/games/game_*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("Found no games")
+	}
+	for _, file := range files {
+		record, err := ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		written := &bytes.Buffer{}
+		if err := record.Write(written); err != nil {
+			t.Fatalf("%v: %v", file, err)
+		}
+		reread, err := Read(bytes.NewReader(written.Bytes()))
+		if err != nil {
+			t.Fatalf("%v: %v", file, err)
+		}
+		if len(reread.Phases) != len(record.Phases) {
+			t.Fatalf("%v: Wanted %v phases, got %v", file, len(record.Phases), len(reread.Phases))
+		}
+		for index, phase := range record.Phases {
+			rephase := reread.Phases[index]
+			if !reflect.DeepEqual(phase.Units, rephase.Units) || !reflect.DeepEqual(phase.Dislodgeds, rephase.Dislodgeds) || !reflect.DeepEqual(phase.SupplyCenters, rephase.SupplyCenters) {
+				t.Errorf("%v: Wanted the positions of %v to survive writing", file, phase)
+			}
+			if len(phase.Orders) != len(rephase.Orders) {
+				t.Errorf("%v: Wanted %v orders in %v, got %v", file, len(phase.Orders), phase, len(rephase.Orders))
+			}
+		}
+		rewritten := &bytes.Buffer{}
+		if err := reread.Write(rewritten); err != nil {
+			t.Fatalf("%v: %v", file, err)
+		}
+		if written.String() != rewritten.String() {
+			t.Errorf("%v: Wanted writing to be stable", file)
+		}
+	}
+}
+
+func TestFromGame(t *testing.T) {
+	g, err := game.New(classical.ClassicalVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, phaseOrders := range []map[godip.Province]godip.Adjudicator{
+		{
+			"par": orders.Move("par", "pic"),
+			"bre": orders.Move("bre", "eng"),
+			"lon": orders.Move("lon", "nth"),
+			"kie": orders.Move("kie", "hol"),
+		},
+		nil,
+		{
+			"pic": orders.Move("pic", "bel"),
+			"nth": orders.SupportMove("nth", "pic", "bel"),
+			"hol": orders.Hold("hol"),
+		},
+		nil,
+		{
+			"bel": orders.Build("bel", godip.Army, time.Now()),
+			"par": orders.Build("par", godip.Army, time.Now()),
+			"lon": orders.Build("lon", godip.Fleet, time.Now()),
+		},
+	} {
+		if err := g.Next(phaseOrders); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record, err := FromGame(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Phases) != len(g.Phases) {
+		t.Fatalf("Wanted %v phases, got %v", len(g.Phases), len(record.Phases))
+	}
+	buf := &bytes.Buffer{}
+	if err := record.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\tnth support pic move bel\n") || !strings.Contains(buf.String(), "\tbuild Fleet lon\n") {
+		t.Errorf("Wanted the orders in the record, got\n%v", buf.String())
+	}
+	reread, err := Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := reread.States(classical.ClassicalVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != len(reread.Phases) {
+		t.Fatalf("Wanted %v states, got %v", len(reread.Phases), len(states))
+	}
+	for index, phase := range reread.Phases {
+		for _, err := range phase.Check(states[index]) {
+			t.Errorf("%v: %v", phase, err)
+		}
+	}
+	if unit, _, ok := states[len(states)-1].Unit("bel"); !ok || unit.Nation != godip.France {
+		t.Errorf("Wanted France in bel after replaying, got %v, %v", unit, ok)
+	}
+}
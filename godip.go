@@ -3,9 +3,11 @@ package godip
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -63,6 +65,7 @@ var (
 	ErrMissingConvoyPath               = fmt.Errorf("ErrMissingConvoyPath")
 	ErrIllegalMove                     = fmt.Errorf("ErrIllegalMove")
 	ErrConvoyParadox                   = fmt.Errorf("ErrConvoyParadox")
+	ErrUnresolvedParadox               = fmt.Errorf("ErrUnresolvedParadox")
 	ErrIllegalSupportPosition          = fmt.Errorf("ErrIllegalSupportPosition")
 	ErrIllegalSupportDestination       = fmt.Errorf("ErrIllegalSupportDestination")
 	ErrIllegalSupportDestinationNation = fmt.Errorf("ErrIllegalSupportDestinationNation")
@@ -154,6 +157,84 @@ func (self ErrBounce) Error() string {
 	return fmt.Sprintf("ErrBounce:%v", self.Province)
 }
 
+var sentinelErrors = map[string]error{}
+
+func init() {
+	for _, err := range []error{
+		ErrInvalidSource,
+		ErrInvalidDestination,
+		ErrInvalidTarget,
+		ErrInvalidPhase,
+		ErrMissingUnit,
+		ErrIllegalDestination,
+		ErrMissingConvoyPath,
+		ErrIllegalMove,
+		ErrConvoyParadox,
+		ErrUnresolvedParadox,
+		ErrIllegalSupportPosition,
+		ErrIllegalSupportDestination,
+		ErrIllegalSupportDestinationNation,
+		ErrMissingSupportUnit,
+		ErrIllegalSupportMove,
+		ErrInvalidSupporteeOrder,
+		ErrIllegalConvoyUnit,
+		ErrIllegalConvoyPath,
+		ErrIllegalConvoyMove,
+		ErrMissingConvoyee,
+		ErrIllegalConvoyer,
+		ErrIllegalConvoyee,
+		ErrIllegalBuild,
+		ErrIllegalDisband,
+		ErrOccupiedSupplyCenter,
+		ErrMissingSupplyCenter,
+		ErrMissingSurplus,
+		ErrIllegalUnitType,
+		ErrMissingDeficit,
+		ErrOccupiedDestination,
+		ErrIllegalRetreat,
+		ErrHostileSupplyCenter,
+		InconsistencyMissingOrder,
+	} {
+		sentinelErrors[err.Error()] = err
+	}
+}
+
+// UnmarshalError returns the error that produced the provided Error() string, so that errors defined in this package
+// survive serialization with their types intact. Unknown errors are returned as plain errors with the same message.
+func UnmarshalError(s string) error {
+	if err, found := sentinelErrors[s]; found {
+		return err
+	}
+	parts := strings.Split(s, ":")
+	switch {
+	case len(parts) == 2 && parts[0] == "ErrBounce":
+		return ErrBounce{Province: Province(parts[1])}
+	case len(parts) == 2 && parts[0] == "ErrSupportBroken":
+		return ErrSupportBroken{Province: Province(parts[1])}
+	case len(parts) == 2 && parts[0] == "ErrConvoyDislodged":
+		return ErrConvoyDislodged{Province: Province(parts[1])}
+	case len(parts) == 2 && parts[0] == "ErrDoubleBuild" && strings.HasPrefix(parts[1], "[") && strings.HasSuffix(parts[1], "]"):
+		result := ErrDoubleBuild{}
+		for _, prov := range strings.Fields(parts[1][1 : len(parts[1])-1]) {
+			result.Provinces = append(result.Provinces, Province(prov))
+		}
+		return result
+	case len(parts) == 2 && parts[0] == "InconsistencyMismatchedSupporter":
+		return InconsistencyMismatchedSupporter{Supportee: Province(parts[1])}
+	case len(parts) == 2 && parts[0] == "InconsistencyMismatchedConvoyee":
+		return InconsistencyMismatchedConvoyee{Convoyer: Province(parts[1])}
+	case len(parts) == 2 && parts[0] == "InconsistencyMismatchedConvoyer":
+		return InconsistencyMismatchedConvoyer{Convoyee: Province(parts[1])}
+	case len(parts) == 6 && parts[0] == "InconsistencyOrderTypeCount" && parts[2] == "Found" && parts[4] == "Want":
+		found, foundErr := strconv.Atoi(parts[3])
+		want, wantErr := strconv.Atoi(parts[5])
+		if foundErr == nil && wantErr == nil {
+			return InconsistencyOrderTypeCount{OrderType: OrderType(parts[1]), Found: found, Want: want}
+		}
+	}
+	return errors.New(s)
+}
+
 var Debug = false
 var LogIndent = []string{}
 var logBuffer = new(bytes.Buffer)
@@ -200,6 +281,58 @@ func DumpLog() {
 	}
 }
 
+// Logger receives the debug log of for example an adjudication.
+type Logger interface {
+	Logf(string, ...interface{})
+	Indent(string)
+	DeIndent()
+}
+
+type globalLogger struct{}
+
+func (globalLogger) Logf(s string, o ...interface{}) {
+	Logf(s, o...)
+}
+
+func (globalLogger) Indent(s string) {
+	Indent(s)
+}
+
+func (globalLogger) DeIndent() {
+	DeIndent()
+}
+
+// GlobalLogger is the default Logger, and logs to the package level log buffer using Logf, Indent and DeIndent, i.e. only when Debug is true.
+var GlobalLogger Logger = globalLogger{}
+
+// IndentingLogger is a Logger that indents each line and hands it to an output function.
+// It is not safe for concurrent use, so use one per state.
+type IndentingLogger struct {
+	output func(string)
+	indent []string
+}
+
+// NewLogger returns a Logger that indents each line and hands it to output.
+func NewLogger(output func(line string)) *IndentingLogger {
+	return &IndentingLogger{
+		output: output,
+	}
+}
+
+func (self *IndentingLogger) Logf(s string, o ...interface{}) {
+	self.output(strings.Join(self.indent, "") + fmt.Sprintf(s, o...))
+}
+
+func (self *IndentingLogger) Indent(s string) {
+	self.indent = append(self.indent, s)
+}
+
+func (self *IndentingLogger) DeIndent() {
+	if len(self.indent) > 0 {
+		self.indent = self.indent[:len(self.indent)-1]
+	}
+}
+
 func Max(is ...int) (result int) {
 	for index, i := range is {
 		if index == 0 || i > result {
@@ -460,10 +593,36 @@ type Adjudicator interface {
 	Order
 	Adjudicate(Resolver) error
 	Execute(State)
+	// Clone returns a copy sharing nothing mutable with this order, since validation refines the targets of orders
+	// (e.g. by picking the only reachable coast of a move).
+	Clone() Adjudicator
 }
 
 type BackupRule func(State, []Province) error
 
+/*
+Rules are the choices made for the issues the DATC leaves to the judge (section 4 of the DATC). The zero value is
+the interpretation preferred by the DATC, and the one godip has always used.
+
+Only the issues where the rulebooks differ in adjudication are options. The README lists the others, and why godip
+always uses the preferred interpretation for them.
+*/
+type Rules struct {
+	// BackupRule is the name of the backup rule, one of BackupRules, used to resolve circular movements and
+	// paradoxes (DATC 4.A.2), e.g. "AllHold" for the 1982 rulebook. The backup rule of the variant is used if empty.
+	BackupRule string `json:",omitempty"`
+	// ExplicitConvoyToAdjacent makes an army moving to an adjacent province only use a convoy if the move is ordered
+	// "via convoy", as in the 2000 rulebook, instead of also when a fleet of the same nation is ordered to convoy it
+	// (DATC 4.A.3).
+	ExplicitConvoyToAdjacent bool `json:",omitempty"`
+	// StrictCoasts makes fleet moves and retreats to provinces with multiple coasts fail unless the coast is given,
+	// even when only one of the coasts can be reached (DATC 4.B.2).
+	StrictCoasts bool `json:",omitempty"`
+	// ReachableSupportCoasts makes fleets only able to support moves to coasts they could move to themselves,
+	// instead of to any coast of a province they can reach (DATC 4.B.4).
+	ReachableSupportCoasts bool `json:",omitempty"`
+}
+
 type StateFilter func(n Province, o Order, u *Unit) bool
 
 // Validator is a game state able to validate orders, but not adjudicate them.
@@ -492,6 +651,10 @@ type Validator interface {
 	MemoizeProvSlice(string, func() []Province) []Province
 
 	Flags() map[Flag]bool
+	// Rules returns the choices made for the issues the DATC leaves to the judge.
+	Rules() Rules
+
+	Logger() Logger
 }
 
 // Resolver is what validators turn into when adjudication has started.
@@ -500,6 +663,176 @@ type Resolver interface {
 
 	AddBounce(src, dst Province)
 	Resolve(Province) error
+	// Explanation returns the explanation of the order currently being adjudicated, or nil if no order is being adjudicated.
+	Explanation() *Explanation
+}
+
+type StrengthType string
+
+const (
+	// AttackStrength is the strength of a move trying to enter its destination.
+	AttackStrength StrengthType = "Attack"
+	// DefendStrength is the strength of a move defending against a move coming the opposite way in a head to head battle.
+	DefendStrength StrengthType = "Defend"
+	// PreventStrength is the strength of a move preventing other moves from entering the same destination.
+	PreventStrength StrengthType = "Prevent"
+	// HoldStrength is the strength of a unit staying in its province.
+	HoldStrength StrengthType = "Hold"
+)
+
+// Strength is a strength calculated while adjudicating an order.
+type Strength struct {
+	Type StrengthType
+	// Province is where the unit with this strength is.
+	Province Province
+	// Target is where the unit with this strength is moving, if it is moving.
+	Target   Province `json:",omitempty"`
+	Strength int
+	// Supports are the provinces of the successful supports that counted towards the strength.
+	Supports []Province
+}
+
+// Explanation describes why an order got its resolution.
+type Explanation struct {
+	Province Province
+	// Resolution is the resolution of the order, nil if it succeeded.
+	Resolution error `json:"-"`
+	// Strengths are the strengths of this order and its opposition that were compared, in order of comparison.
+	Strengths []Strength `json:",omitempty"`
+	// CutBy are the provinces of the moves that cut this support.
+	CutBy []Province `json:",omitempty"`
+	// DislodgedBy are the provinces of the moves that dislodged this support or convoy.
+	DislodgedBy []Province `json:",omitempty"`
+	// Convoyed is true if this move had to be convoyed.
+	Convoyed bool `json:",omitempty"`
+	// ConvoyPath is the successful convoy path found for a convoyed move, if any, from the first convoying fleet to the destination.
+	ConvoyPath []Province `json:",omitempty"`
+	// ConvoyPathCandidates are the convoy paths considered for a convoyed move, through the fleets ordered to convoy it.
+	ConvoyPathCandidates []ConvoyPathCandidate `json:",omitempty"`
+	// BackupRule is true if the resolution was decided by the backup rule.
+	BackupRule bool `json:",omitempty"`
+}
+
+// ConvoyPathCandidate is a convoy path considered for a convoyed move.
+type ConvoyPathCandidate struct {
+	// Path is the provinces of the path, from the first convoying fleet to the destination.
+	Path []Province
+	// Failures are the resolutions of the failed convoys along the path, by the province of the convoying fleet.
+	// The path was rejected if there are any.
+	Failures map[Province]error `json:"-"`
+}
+
+func (self ConvoyPathCandidate) MarshalJSON() ([]byte, error) {
+	type candidate ConvoyPathCandidate
+	failures := map[Province]string{}
+	for prov, err := range self.Failures {
+		failures[prov] = err.Error()
+	}
+	return json.Marshal(struct {
+		candidate
+		Failures map[Province]string `json:",omitempty"`
+	}{
+		candidate: candidate(self),
+		Failures:  failures,
+	})
+}
+
+func (self *ConvoyPathCandidate) UnmarshalJSON(b []byte) error {
+	decoded := struct {
+		Path     []Province
+		Failures map[Province]string
+	}{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	self.Path = decoded.Path
+	self.Failures = nil
+	for prov, failure := range decoded.Failures {
+		if self.Failures == nil {
+			self.Failures = map[Province]error{}
+		}
+		self.Failures[prov] = UnmarshalError(failure)
+	}
+	return nil
+}
+
+func (self *Explanation) AddStrength(strength Strength) {
+	if self != nil {
+		self.Strengths = append(self.Strengths, strength)
+	}
+}
+
+func (self *Explanation) SetCutBy(provinces []Province) {
+	if self != nil {
+		self.CutBy = provinces
+	}
+}
+
+func (self *Explanation) SetDislodgedBy(provinces []Province) {
+	if self != nil {
+		self.DislodgedBy = provinces
+	}
+}
+
+func (self *Explanation) SetConvoyPath(path []Province) {
+	if self != nil {
+		self.Convoyed = true
+		self.ConvoyPath = path
+	}
+}
+
+func (self *Explanation) SetConvoyPathCandidates(paths [][]Province) {
+	if self != nil {
+		self.ConvoyPathCandidates = nil
+		for _, path := range paths {
+			self.ConvoyPathCandidates = append(self.ConvoyPathCandidates, ConvoyPathCandidate{Path: path})
+		}
+	}
+}
+
+// Copy returns a deep copy of the explanation.
+func (self *Explanation) Copy() *Explanation {
+	if self == nil {
+		return nil
+	}
+	result := *self
+	result.Strengths = nil
+	for _, strength := range self.Strengths {
+		strength.Supports = append([]Province(nil), strength.Supports...)
+		result.Strengths = append(result.Strengths, strength)
+	}
+	result.CutBy = append([]Province(nil), self.CutBy...)
+	result.DislodgedBy = append([]Province(nil), self.DislodgedBy...)
+	result.ConvoyPath = append([]Province(nil), self.ConvoyPath...)
+	result.ConvoyPathCandidates = nil
+	for _, candidate := range self.ConvoyPathCandidates {
+		candidate.Path = append([]Province(nil), candidate.Path...)
+		failures := candidate.Failures
+		candidate.Failures = nil
+		for prov, err := range failures {
+			if candidate.Failures == nil {
+				candidate.Failures = map[Province]error{}
+			}
+			candidate.Failures[prov] = err
+		}
+		result.ConvoyPathCandidates = append(result.ConvoyPathCandidates, candidate)
+	}
+	return &result
+}
+
+func (self Explanation) MarshalJSON() ([]byte, error) {
+	type explanation Explanation
+	resolution := "OK"
+	if self.Resolution != nil {
+		resolution = self.Resolution.Error()
+	}
+	return json.Marshal(struct {
+		explanation
+		Resolution string
+	}{
+		explanation: explanation(self),
+		Resolution:  resolution,
+	})
 }
 
 type Inconsistency struct {
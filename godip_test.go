@@ -304,3 +304,25 @@ func TestNationEqual(t *testing.T) {
 		t.Errorf("Wanted inequal, was: %+v, %+v", n, o)
 	}
 }
+
+func TestUnmarshalError(t *testing.T) {
+	for _, err := range []error{
+		ErrIllegalMove,
+		InconsistencyMissingOrder,
+		ErrBounce{Province: "bur"},
+		ErrSupportBroken{Province: "stp/nc"},
+		ErrConvoyDislodged{Province: "nth"},
+		ErrDoubleBuild{Provinces: []Province{"lon", "edi"}},
+		InconsistencyMismatchedSupporter{Supportee: "par"},
+		InconsistencyMismatchedConvoyee{Convoyer: "nth"},
+		InconsistencyMismatchedConvoyer{Convoyee: "lon"},
+		InconsistencyOrderTypeCount{OrderType: Build, Found: 3, Want: 2},
+	} {
+		if found := UnmarshalError(err.Error()); !reflect.DeepEqual(found, err) {
+			t.Errorf("Wanted %#v, got %#v", err, found)
+		}
+	}
+	if found := UnmarshalError("No unit at par"); found.Error() != "No unit at par" {
+		t.Errorf("Wanted unknown errors to keep their message, got %v", found)
+	}
+}
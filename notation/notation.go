@@ -0,0 +1,392 @@
+package notation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/variants/common"
+)
+
+var (
+	coastReg = regexp.MustCompile(`\s*\(\s*([A-Za-z]+)\s*\)`)
+	arrowReg = regexp.MustCompile(`->|–|—|=>`)
+)
+
+var (
+	moveWords    = []string{"-", "to", "m", "move", "moves", "r", "retreat", "retreats"}
+	holdWords    = []string{"h", "hold", "holds", "stand", "stands"}
+	supportWords = []string{"s", "support", "supports"}
+	convoyWords  = []string{"c", "convoy", "convoys"}
+	buildWords   = []string{"b", "build", "builds"}
+	disbandWords = []string{"d", "disband", "disbands", "remove", "removes"}
+	toWords      = []string{"to"}
+	viaWords     = []string{"via", "by"}
+	armyWords    = []string{"a", "army"}
+	fleetWords   = []string{"f", "fleet"}
+)
+
+// minPrefixSize is the shortest prefix of a long name that is matched against the long names of provinces.
+const minPrefixSize = 3
+
+/*
+Notation parses and formats orders written the way players write them, e.g. "A Par - Bur", "F NTH C A Lon - Nwy",
+"A Mun S A Kie - Ber", "Build F StP/nc" or "A Ruh disband".
+
+Provinces can be given by their abbreviation or their long name (case insensitively), or by an unambiguous prefix of
+their long name, and coasts either as "stp/nc" or "stp (nc)". Unit types are optional, except for builds.
+*/
+type Notation struct {
+	variant common.Variant
+	graph   godip.Graph
+	// names contains the normalized abbreviations and long names of all provinces.
+	names map[string]godip.Province
+	// longNames contains the normalized long names of all provinces, for prefix matching.
+	longNames map[string]godip.Province
+	// maxNameWords is the number of words in the longest name.
+	maxNameWords int
+}
+
+func normalize(name string) string {
+	name = strings.ToLower(coastReg.ReplaceAllString(name, "/$1"))
+	name = strings.NewReplacer(".", "", "'", "").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// New returns a notation for orders of variant.
+func New(variant common.Variant) *Notation {
+	result := &Notation{
+		variant:   variant,
+		graph:     variant.Graph(),
+		names:     map[string]godip.Province{},
+		longNames: map[string]godip.Province{},
+	}
+	add := func(name string, prov godip.Province) {
+		normalized := normalize(name)
+		result.names[normalized] = prov
+		if words := len(strings.Fields(normalized)); words > result.maxNameWords {
+			result.maxNameWords = words
+		}
+	}
+	for _, prov := range result.graph.Provinces() {
+		add(string(prov), prov)
+	}
+	for prov, longName := range variant.ProvinceLongNames {
+		if result.graph.Has(prov) {
+			add(longName, prov)
+			result.longNames[normalize(longName)] = prov
+		}
+	}
+	return result
+}
+
+func isWord(token string, words []string) bool {
+	for _, word := range words {
+		if token == word {
+			return true
+		}
+	}
+	return false
+}
+
+type tokens struct {
+	notation *Notation
+	text     string
+	parts    []string
+}
+
+func (self *Notation) tokenize(text string) *tokens {
+	result := &tokens{
+		notation: self,
+		text:     text,
+	}
+	for _, part := range strings.Fields(normalize(arrowReg.ReplaceAllString(text, " - "))) {
+		if part == "-" || !strings.Contains(part, "-") {
+			result.parts = append(result.parts, part)
+			continue
+		}
+		if _, found := self.names[part]; found {
+			result.parts = append(result.parts, part)
+			continue
+		}
+		for index, subPart := range strings.Split(part, "-") {
+			if index > 0 {
+				result.parts = append(result.parts, "-")
+			}
+			if subPart != "" {
+				result.parts = append(result.parts, subPart)
+			}
+		}
+	}
+	return result
+}
+
+func (self *tokens) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Can't parse %q: %v", self.text, fmt.Sprintf(format, args...))
+}
+
+func (self *tokens) done() bool {
+	return len(self.parts) == 0
+}
+
+func (self *tokens) peek(words []string) bool {
+	return len(self.parts) > 0 && isWord(self.parts[0], words)
+}
+
+func (self *tokens) accept(words []string) bool {
+	if self.peek(words) {
+		self.parts = self.parts[1:]
+		return true
+	}
+	return false
+}
+
+func (self *tokens) unitType() godip.UnitType {
+	if len(self.parts) > 1 {
+		if self.accept(armyWords) {
+			return godip.Army
+		}
+		if self.accept(fleetWords) {
+			return godip.Fleet
+		}
+	}
+	return ""
+}
+
+// province consumes the longest sequence of tokens naming a province.
+func (self *tokens) province() (godip.Province, error) {
+	if self.done() {
+		return "", self.errorf("missing province")
+	}
+	for words := self.notation.maxNameWords; words > 0; words-- {
+		if words > len(self.parts) {
+			continue
+		}
+		name := strings.Join(self.parts[:words], " ")
+		if prov, found := self.notation.names[name]; found {
+			self.parts = self.parts[words:]
+			return prov, nil
+		}
+	}
+	prefix := self.parts[0]
+	if len(prefix) >= minPrefixSize {
+		var match godip.Province
+		for longName, prov := range self.notation.longNames {
+			if strings.HasPrefix(longName, prefix) && prov == prov.Super() {
+				if match != "" && match != prov {
+					return "", self.errorf("%q is ambiguous", prefix)
+				}
+				match = prov
+			}
+		}
+		if match != "" {
+			self.parts = self.parts[1:]
+			return match, nil
+		}
+	}
+	return "", self.errorf("unknown province %q", prefix)
+}
+
+// inferCoast returns the only coast of dst a fleet at src can move to, if dst has coasts and only one of them is
+// reachable.
+func (self *Notation) inferCoast(src, dst godip.Province) godip.Province {
+	if dst != dst.Super() {
+		return dst
+	}
+	reachable := map[godip.Province]bool{}
+	for _, srcCoast := range self.graph.Coasts(src) {
+		for _, dstCoast := range self.graph.Coasts(dst) {
+			if dstCoast == dstCoast.Super() {
+				continue
+			}
+			if _, found := self.graph.Edges(srcCoast, false)[dstCoast]; found {
+				reachable[dstCoast] = true
+			}
+		}
+	}
+	if len(reachable) == 1 {
+		for coast := range reachable {
+			return coast
+		}
+	}
+	return dst
+}
+
+// Parse returns the order described by text, as parsed by the parser of the variant.
+func (self *Notation) Parse(text string) (godip.Adjudicator, error) {
+	bits, err := self.bits(self.tokenize(text))
+	if err != nil {
+		return nil, err
+	}
+	return self.variant.Parser.Parse(bits)
+}
+
+func (self *Notation) bits(t *tokens) ([]string, error) {
+	if t.accept(buildWords) {
+		typ := t.unitType()
+		prov, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		if typ == "" {
+			return nil, t.errorf("missing unit type to build")
+		}
+		return t.finish([]string{string(prov), string(godip.Build), string(typ)})
+	}
+	if t.accept(disbandWords) {
+		t.unitType()
+		prov, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		return t.finish([]string{string(prov), string(godip.Disband)})
+	}
+	typ := t.unitType()
+	src, err := t.province()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t.accept(moveWords):
+		t.accept(toWords)
+		dst, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		if typ == godip.Fleet {
+			dst = self.inferCoast(src, dst)
+		}
+		orderType := godip.Move
+		if t.accept(viaWords) {
+			if !t.accept(convoyWords) {
+				return nil, t.errorf("expected convoy")
+			}
+			orderType = godip.MoveViaConvoy
+		}
+		return t.finish([]string{string(src), string(orderType), string(dst)})
+	case t.accept(holdWords):
+		return t.finish([]string{string(src), string(godip.Hold)})
+	case t.accept(supportWords):
+		t.unitType()
+		from, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if t.accept(moveWords) {
+			if to, err = t.province(); err != nil {
+				return nil, err
+			}
+		} else {
+			t.accept(holdWords)
+		}
+		return t.finish([]string{string(src), string(godip.Support), string(from), string(to)})
+	case t.accept(convoyWords):
+		t.unitType()
+		from, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		if !t.accept(moveWords) {
+			return nil, t.errorf("expected the destination of the convoy")
+		}
+		to, err := t.province()
+		if err != nil {
+			return nil, err
+		}
+		return t.finish([]string{string(src), string(godip.Convoy), string(from), string(to)})
+	case t.accept(buildWords):
+		if typ == "" {
+			return nil, t.errorf("missing unit type to build")
+		}
+		return t.finish([]string{string(src), string(godip.Build), string(typ)})
+	case t.accept(disbandWords):
+		return t.finish([]string{string(src), string(godip.Disband)})
+	}
+	if t.done() {
+		return nil, t.errorf("missing order type")
+	}
+	return nil, t.errorf("unknown order type %q", t.parts[0])
+}
+
+func (self *tokens) finish(bits []string) ([]string, error) {
+	if !self.done() {
+		return nil, self.errorf("unexpected %q", strings.Join(self.parts, " "))
+	}
+	return bits, nil
+}
+
+func (self *Notation) province(prov godip.Province) string {
+	s := string(prov)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func unitPrefix(unit godip.Unit, _ godip.Province, found bool) string {
+	if !found {
+		return ""
+	}
+	switch unit.Type {
+	case godip.Army:
+		return "A "
+	case godip.Fleet:
+		return "F "
+	}
+	return ""
+}
+
+func (self *Notation) unit(v godip.Validator, prov godip.Province) string {
+	if v == nil {
+		return ""
+	}
+	if v.Phase().Type() == godip.Retreat {
+		return unitPrefix(v.Dislodged(prov))
+	}
+	return unitPrefix(v.Unit(prov))
+}
+
+/*
+Format returns order in the notation understood by Parse.
+
+If v is provided the types of the units involved are looked up and included, e.g. "F Nth C A Lon - Nwy" instead of
+"Nth C Lon - Nwy".
+*/
+func (self *Notation) Format(v godip.Validator, order godip.Order) (string, error) {
+	encoded, err := orders.Encode(order)
+	if err != nil {
+		return "", err
+	}
+	bits := make([]godip.Province, len(encoded.Bits))
+	for index, bit := range encoded.Bits {
+		bits[index] = godip.Province(bit)
+	}
+	if len(bits) < 2 {
+		return "", fmt.Errorf("Can't format %v", order)
+	}
+	src := self.unit(v, bits[0]) + self.province(bits[0])
+	switch godip.OrderType(bits[1]) {
+	case godip.Move:
+		return fmt.Sprintf("%v - %v", src, self.province(bits[2])), nil
+	case godip.MoveViaConvoy:
+		return fmt.Sprintf("%v - %v via convoy", src, self.province(bits[2])), nil
+	case godip.Hold:
+		return fmt.Sprintf("%v H", src), nil
+	case godip.Support:
+		supported := self.unit(v, bits[2]) + self.province(bits[2])
+		if bits[2] == bits[3] {
+			return fmt.Sprintf("%v S %v", src, supported), nil
+		}
+		return fmt.Sprintf("%v S %v - %v", src, supported, self.province(bits[3])), nil
+	case godip.Convoy:
+		return fmt.Sprintf("%v C %v - %v", src, self.unit(v, bits[2])+self.province(bits[2]), self.province(bits[3])), nil
+	case godip.Build:
+		return fmt.Sprintf("Build %v%v", unitPrefix(godip.Unit{Type: godip.UnitType(bits[2])}, "", true), self.province(bits[0])), nil
+	case godip.Disband:
+		return fmt.Sprintf("%v Disband", src), nil
+	}
+	return "", fmt.Errorf("Can't format %v", order)
+}
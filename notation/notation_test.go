@@ -0,0 +1,111 @@
+package notation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/variants/classical"
+)
+
+func assertParse(t *testing.T, n *Notation, text string, wanted []string) {
+	order, err := n.Parse(text)
+	if err != nil {
+		t.Errorf("Parsing %q: %v", text, err)
+		return
+	}
+	encoded, err := orders.Encode(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded.Bits, wanted) {
+		t.Errorf("Parsing %q: wanted %v, got %v", text, wanted, encoded.Bits)
+	}
+}
+
+func TestParse(t *testing.T) {
+	n := New(classical.ClassicalVariant)
+	for text, wanted := range map[string][]string{
+		"A Par - Bur":                     {"par", "Move", "bur"},
+		"a par-bur":                       {"par", "Move", "bur"},
+		"Paris -> Burgundy":               {"par", "Move", "bur"},
+		"A Paris moves to Burgundy":       {"par", "Move", "bur"},
+		"A Lon - Nwy via convoy":          {"lon", "MoveViaConvoy", "nwy"},
+		"F NTH C A Lon - Nwy":             {"nth", "Convoy", "lon", "nwy"},
+		"F North Sea C A London - Norway": {"nth", "Convoy", "lon", "nwy"},
+		"A Mun S A Kie - Ber":             {"mun", "Support", "kie", "ber"},
+		"A Mun S Kie":                     {"mun", "Support", "kie", "kie"},
+		"A Mun S A Kie H":                 {"mun", "Support", "kie", "kie"},
+		"A Par H":                         {"par", "Hold"},
+		"Par holds":                       {"par", "Hold"},
+		"Build F StP/nc":                  {"stp/nc", "Build", "Fleet"},
+		"Build F StP (NC)":                {"stp/nc", "Build", "Fleet"},
+		"Build fleet St. Petersburg (NC)": {"stp/nc", "Build", "Fleet"},
+		"F Stp Build":                     {"stp", "Build", "Fleet"},
+		"A Ruh disband":                   {"ruh", "Disband"},
+		"Remove A Ruhr":                   {"ruh", "Disband"},
+		"F Bot - StP":                     {"bot", "Move", "stp/sc"},
+		"F Bar - StP":                     {"bar", "Move", "stp/nc"},
+		"F Mid - Spa":                     {"mid", "Move", "spa"},
+		"A Bohem - Gali":                  {"boh", "Move", "gal"},
+	} {
+		assertParse(t, n, text, wanted)
+	}
+	for _, text := range []string{
+		"",
+		"A Par",
+		"A Par - Xyz",
+		"A Par jumps Bur",
+		"Build Par",
+		"A Par - Bur now",
+		"F Nth C A Lon",
+	} {
+		if order, err := n.Parse(text); err == nil {
+			t.Errorf("Wanted an error parsing %q, got %v", text, order)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	n := New(classical.ClassicalVariant)
+	judge := classical.Blank(classical.NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("nth", godip.Unit{godip.Fleet, godip.England})
+	judge.SetUnit("lon", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("mun", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("kie", godip.Unit{godip.Army, godip.Germany})
+	for _, tc := range []struct {
+		order     godip.Order
+		formatted string
+	}{
+		{orders.Move("lon", "nwy"), "A Lon - Nwy"},
+		{orders.Move("lon", "nwy").ViaConvoy(), "A Lon - Nwy via convoy"},
+		{orders.Convoy("nth", "lon", "nwy"), "F Nth C A Lon - Nwy"},
+		{orders.SupportMove("mun", "kie", "ber"), "A Mun S A Kie - Ber"},
+		{orders.SupportHold("mun", "kie"), "A Mun S A Kie"},
+		{orders.Hold("nth"), "F Nth H"},
+		{orders.Build("stp/nc", godip.Fleet, time.Now()), "Build F Stp/nc"},
+		{orders.Disband("kie", time.Now()), "A Kie Disband"},
+	} {
+		formatted, err := n.Format(judge, tc.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted != tc.formatted {
+			t.Errorf("Wanted %q, got %q", tc.formatted, formatted)
+		}
+		parsed, err := n.Parse(formatted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wanted, _ := orders.Encode(tc.order)
+		found, _ := orders.Encode(parsed)
+		if !reflect.DeepEqual(wanted.Bits, found.Bits) {
+			t.Errorf("Wanted %q to parse back to %v, got %v", formatted, wanted.Bits, found.Bits)
+		}
+	}
+	if formatted, err := n.Format(nil, orders.Move("par", "bur")); err != nil || formatted != "Par - Bur" {
+		t.Errorf("Wanted %q without a validator, got %q, %v", "Par - Bur", formatted, err)
+	}
+}
@@ -79,10 +79,23 @@ func (self *build) String() string {
 	return fmt.Sprintf("%v %v %v", self.targets[0], godip.Build, self.typ)
 }
 
+func (self *build) Bits() []string {
+	return []string{string(self.targets[0]), string(godip.Build), string(self.typ)}
+}
+
 func (self *build) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *build) Clone() godip.Adjudicator {
+	return &build{
+		targets: append([]godip.Province{}, self.targets...),
+		typ:     self.typ,
+		at:      self.at,
+		flags:   copyFlags(self.flags),
+	}
+}
+
 func (self *build) At() time.Time {
 	return self.at
 }
@@ -3,6 +3,7 @@ package orders
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/zond/godip"
@@ -24,6 +25,10 @@ func (self *convoy) String() string {
 	return fmt.Sprintf("%v %v %v", self.targets[0], godip.Convoy, self.targets[1:])
 }
 
+func (self *convoy) Bits() []string {
+	return []string{string(self.targets[0]), string(godip.Convoy), string(self.targets[1]), string(self.targets[2])}
+}
+
 func (self *convoy) Flags() map[godip.Flag]bool {
 	return nil
 }
@@ -44,6 +49,12 @@ func (self *convoy) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *convoy) Clone() godip.Adjudicator {
+	return &convoy{
+		targets: append([]godip.Province{}, self.targets...),
+	}
+}
+
 func (self *convoy) Corroborate(v godip.Validator) []error {
 	unit, _, _ := v.Unit(self.targets[0])
 	me := unit.Nation
@@ -74,6 +85,7 @@ func (self *convoy) Adjudicate(r godip.Resolver) error {
 			u.Nation != unit.Nation && // not friendly
 			r.Resolve(p) == nil)
 	}); len(breaks) > 0 {
+		r.Explanation().SetDislodgedBy(breaks)
 		return godip.ErrConvoyDislodged{breaks[0]}
 	}
 	return nil
@@ -115,22 +127,20 @@ func (self *convoy) Options(v godip.Validator, nation godip.Nation, src godip.Pr
 	}
 	possibleSources := []godip.Province{}
 	possibleDestinations := []godip.Province{}
-	for _, endpointProv := range v.Graph().Provinces() {
-		for _, endpoint := range v.Graph().Coasts(endpointProv) {
-			if !v.Graph().Flags(endpoint)[godip.Land] {
-				continue
-			}
-			if path := (ConvoyPathFinder{
-				ConvoyPathFilter: ConvoyPathFilter{
-					Validator:   v,
-					Source:      actualSrc,
-					Destination: endpoint,
-				},
-			}).Path(); path != nil {
-				possibleDestinations = append(possibleDestinations, endpoint)
-				if endpointUnit, _, ok := v.Unit(endpoint); ok && endpointUnit.Type == godip.Army {
-					possibleSources = append(possibleSources, endpoint)
-				}
+	for _, endpoint := range convoyEndPointCandidates(v, actualSrc) {
+		if !v.Graph().Flags(endpoint)[godip.Land] {
+			continue
+		}
+		if path := (ConvoyPathFinder{
+			ConvoyPathFilter: ConvoyPathFilter{
+				Validator:   v,
+				Source:      actualSrc,
+				Destination: endpoint,
+			},
+		}).Path(); path != nil {
+			possibleDestinations = append(possibleDestinations, endpoint)
+			if endpointUnit, _, ok := v.Unit(endpoint); ok && endpointUnit.Type == godip.Army {
+				possibleSources = append(possibleSources, endpoint)
 			}
 		}
 	}
@@ -240,6 +250,41 @@ func ConvoyEndPoints(v godip.Validator, startPoint godip.Province, reverse bool,
 	return result
 }
 
+/*
+convoyEndPointCandidates returns the provinces a convoy path from the fleet at src can possibly end in, to avoid looking
+for paths to every province of the graph.
+
+A path from src can only pass fleets that could convoy, and the super province of its destination, so the destination
+is a coast of a neighbour of src or of a fleet reachable from src via fleets that could convoy.
+*/
+func convoyEndPointCandidates(v godip.Validator, src godip.Province) []godip.Province {
+	filter := ConvoyPathFilter{
+		Validator: v,
+		Source:    src,
+	}
+	reachable := map[godip.Province]bool{src: true}
+	v.Graph().Path(src, "", false, func(prov godip.Province, edgeFlags, provFlags map[godip.Flag]bool, sc *godip.Nation, trace []godip.Province) bool {
+		if filter.PathFilter(prov, edgeFlags, provFlags, sc, trace) {
+			reachable[prov] = true
+			return true
+		}
+		return false
+	})
+	candidates := map[godip.Province]bool{}
+	for prov := range reachable {
+		for neighbour := range v.Graph().Edges(prov, false) {
+			for _, coast := range v.Graph().Coasts(neighbour) {
+				candidates[coast] = true
+			}
+		}
+	}
+	result := make([]godip.Province, 0, len(candidates))
+	for prov := range candidates {
+		result = append(result, prov)
+	}
+	return result
+}
+
 type ConvoyPathFilter struct {
 	Validator   godip.Validator
 	Source      godip.Province
@@ -390,10 +435,40 @@ func (c ConvoyPathFinder) Any() []godip.Province {
 	return nil
 }
 
+// ConvoyPathCandidates returns the convoy paths from src to dst through each fleet ordered to convoy the unit at src
+// to dst, without resolving the convoys. Used to explain which convoy paths a convoyed move had.
+func ConvoyPathCandidates(v godip.Validator, src godip.Province, dst godip.Province) [][]godip.Province {
+	fleets, _, _ := v.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
+		return u != nil && u.Type == godip.Fleet && o != nil && o.Type() == godip.Convoy &&
+			o.Targets()[1].Contains(src) && o.Targets()[2].Contains(dst)
+	})
+	sort.Slice(fleets, func(i, j int) bool {
+		return fleets[i] < fleets[j]
+	})
+	result := [][]godip.Province{}
+	found := map[string]bool{}
+	for i := range fleets {
+		path := (ConvoyPathFinder{
+			ConvoyPathFilter: ConvoyPathFilter{
+				Validator:          v,
+				Source:             src,
+				Destination:        dst,
+				VerifyConvoyOrders: true,
+			},
+			ViaProvince: &fleets[i],
+		}).Any()
+		if key := fmt.Sprint(path); len(path) > 1 && !found[key] {
+			found[key] = true
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
 // MustConvoy returns whether the unit at src must convoy.
 // Used during adjudication to find mandatory convoy path, i.e. if there is no other option,
-// or there is a convoy option and the move is via convoy, or the owner has told at least one fleet
-// to convoy the unit that way.
+// or there is a convoy option and the move is via convoy, or (unless the ExplicitConvoyToAdjacent rule is
+// used) the owner has told at least one fleet to convoy the unit that way.
 func MustConvoy(r godip.Resolver, src godip.Province) bool {
 	unit, _, ok := r.Unit(src)
 	if !ok {
@@ -418,13 +493,13 @@ func MustConvoy(r godip.Resolver, src godip.Province) bool {
 				VerifyConvoyOrders:     true,
 				MinLengthAtDestination: 1,
 			}}).Any()) > 1) ||
-		len((ConvoyPathFinder{
+		(!r.Rules().ExplicitConvoyToAdjacent && len((ConvoyPathFinder{
 			ConvoyPathFilter: ConvoyPathFilter{
 				Validator:   r,
 				Source:      order.Targets()[0],
 				Destination: order.Targets()[1],
 			},
 			ViaNation: &unit.Nation,
-		}).Any()) > 1)
+		}).Any()) > 1))
 	return rval
 }
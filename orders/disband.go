@@ -25,6 +25,10 @@ func (self *disband) String() string {
 	return fmt.Sprintf("%v %v", self.targets[0], godip.Disband)
 }
 
+func (self *disband) Bits() []string {
+	return []string{string(self.targets[0]), string(godip.Disband)}
+}
+
 func (self *disband) Type() godip.OrderType {
 	return godip.Disband
 }
@@ -45,6 +49,13 @@ func (self *disband) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *disband) Clone() godip.Adjudicator {
+	return &disband{
+		targets: append([]godip.Province{}, self.targets...),
+		at:      self.at,
+	}
+}
+
 func (self *disband) At() time.Time {
 	return self.at
 }
@@ -0,0 +1,52 @@
+package orders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zond/godip"
+)
+
+// Encoded is the serializable form of an order.
+type Encoded struct {
+	// Bits are the parts of the order, as accepted by Parser.Parse.
+	Bits []string
+	// At is the time of builds and disbands, which decides which of them are used when too many are given.
+	At *time.Time `json:",omitempty"`
+}
+
+// Encode returns the serializable form of an order created by this package.
+func Encode(order godip.Order) (Encoded, error) {
+	bitser, ok := order.(interface {
+		Bits() []string
+	})
+	if !ok {
+		return Encoded{}, fmt.Errorf("Can't encode %v", order)
+	}
+	result := Encoded{
+		Bits: bitser.Bits(),
+	}
+	switch order.(type) {
+	case *build, *disband:
+		at := order.At()
+		result.At = &at
+	}
+	return result, nil
+}
+
+// Decode returns the order represented by encoded, using the prototypes of this parser.
+func (self Parser) Decode(encoded Encoded) (godip.Adjudicator, error) {
+	result, err := self.Parse(encoded.Bits)
+	if err != nil {
+		return nil, err
+	}
+	if encoded.At != nil {
+		switch order := result.(type) {
+		case *build:
+			order.at = *encoded.At
+		case *disband:
+			order.at = *encoded.At
+		}
+	}
+	return result, nil
+}
@@ -23,6 +23,10 @@ func (self *hold) String() string {
 	return fmt.Sprintf("%v %v", self.targets[0], godip.Hold)
 }
 
+func (self *hold) Bits() []string {
+	return []string{string(self.targets[0]), string(godip.Hold)}
+}
+
 func (self *hold) Flags() map[godip.Flag]bool {
 	return nil
 }
@@ -43,6 +47,12 @@ func (self *hold) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *hold) Clone() godip.Adjudicator {
+	return &hold{
+		targets: append([]godip.Province{}, self.targets...),
+	}
+}
+
 func (self *hold) At() time.Time {
 	return time.Now()
 }
@@ -106,7 +116,14 @@ func (self *hold) Execute(state godip.State) {
 HoldSupport returns successful supports of a hold in prov.
 */
 func HoldSupport(r godip.Resolver, prov godip.Province) int {
-	_, supports, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
+	return len(HoldSupporters(r, prov))
+}
+
+/*
+HoldSupporters returns the provinces of the successful supports of a hold in prov.
+*/
+func HoldSupporters(r godip.Resolver, prov godip.Province) []godip.Province {
+	supporters, _, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
 		if o != nil && u != nil && o.Type() == godip.Support && p.Super() != prov.Super() && len(o.Targets()) == 2 && o.Targets()[1].Super() == prov.Super() {
 			if err := r.Resolve(p); err == nil {
 				return true
@@ -114,5 +131,5 @@ func HoldSupport(r godip.Resolver, prov godip.Province) int {
 		}
 		return false
 	})
-	return len(supports)
+	return supporters
 }
@@ -35,6 +35,10 @@ func (self *move) String() string {
 	return fmt.Sprintf("%v %v %v%v", self.targets[0], godip.Move, self.targets[1], via)
 }
 
+func (self *move) Bits() []string {
+	return []string{string(self.targets[0]), string(self.DisplayType()), string(self.targets[1])}
+}
+
 func (self *move) ViaConvoy() *move {
 	self.flags[godip.ViaConvoy] = true
 	return self
@@ -55,6 +59,13 @@ func (self *move) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *move) Clone() godip.Adjudicator {
+	return &move{
+		targets: append([]godip.Province{}, self.targets...),
+		flags:   copyFlags(self.flags),
+	}
+}
+
 func (self *move) Corroborate(v godip.Validator) []error {
 	rval := []error{}
 	unit, _, _ := v.Unit(self.targets[0])
@@ -118,6 +129,12 @@ func (self *move) Adjudicate(r godip.Resolver) error {
 func (self *move) adjudicateRetreatPhase(r godip.Resolver) error {
 	for prov, order := range r.Orders() {
 		if prov.Super() != self.targets[0].Super() && order.Type() == godip.Move && order.Targets()[1].Super() == self.targets[1].Super() {
+			r.Explanation().AddStrength(godip.Strength{
+				Type:     godip.PreventStrength,
+				Province: order.Targets()[0],
+				Target:   order.Targets()[1],
+				Strength: 1,
+			})
 			return godip.ErrBounce{order.Targets()[0]}
 		}
 	}
@@ -137,9 +154,9 @@ func (self *move) adjudicateAgainstCompetition(r godip.Resolver, forbiddenSuppor
 		if forbiddenSupporter != nil {
 			forbiddenSupporters = append(forbiddenSupporters, *forbiddenSupporter)
 		}
-		attackStrength := MoveSupport(r, self.targets[0], self.targets[1], forbiddenSupporters) + 1
-		godip.Logf("'%v' vs '%v': %v", self, competingOrder, attackStrength)
-		if as := MoveSupport(r, competingOrder.Targets()[0], competingOrder.Targets()[1], nil) + 1; as >= attackStrength {
+		attackStrength := moveStrength(r, godip.AttackStrength, self.targets[0], self.targets[1], forbiddenSupporters)
+		r.Logger().Logf("'%v' vs '%v': %v", self, competingOrder, attackStrength)
+		if as := moveStrength(r, godip.PreventStrength, competingOrder.Targets()[0], competingOrder.Targets()[1], nil); as >= attackStrength {
 			if MustConvoy(r, competingOrder.Targets()[0]) {
 				if len((ConvoyPathFinder{
 					ConvoyPathFilter: ConvoyPathFilter{
@@ -149,13 +166,13 @@ func (self *move) adjudicateAgainstCompetition(r godip.Resolver, forbiddenSuppor
 						ResolveConvoys:         true,
 						MinLengthAtDestination: 1,
 					}}).Any()) > 1 {
-					godip.Logf("'%v' vs '%v': %v", competingOrder, self, as)
+					r.Logger().Logf("'%v' vs '%v': %v", competingOrder, self, as)
 					r.AddBounce(self.targets[0], self.targets[1])
 					return godip.ErrBounce{competingOrder.Targets()[0]}
 				}
 			} else {
-				godip.Logf("H2HDisl(%v)", self.targets[1])
-				godip.Indent("  ")
+				r.Logger().Logf("H2HDisl(%v)", self.targets[1])
+				r.Logger().Indent("  ")
 				if dislodgers, _, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
 					res := o != nil && // is an order
 						u != nil && // is a unit
@@ -170,18 +187,18 @@ func (self *move) adjudicateAgainstCompetition(r godip.Resolver, forbiddenSuppor
 					}
 					return false
 				}); len(dislodgers) == 0 {
-					godip.DeIndent()
-					godip.Logf("Not dislodged")
-					godip.Logf("'%v' vs '%v': %v", competingOrder, self, as)
+					r.Logger().DeIndent()
+					r.Logger().Logf("Not dislodged")
+					r.Logger().Logf("'%v' vs '%v': %v", competingOrder, self, as)
 					r.AddBounce(self.targets[0], self.targets[1])
 					return godip.ErrBounce{competingOrder.Targets()[0]}
 				} else {
-					godip.DeIndent()
-					godip.Logf("Dislodged by %v", dislodgers)
+					r.Logger().DeIndent()
+					r.Logger().Logf("Dislodged by %v", dislodgers)
 				}
 			}
 		} else {
-			godip.Logf("'%v' vs '%v': %v", competingOrder, self, as)
+			r.Logger().Logf("'%v' vs '%v': %v", competingOrder, self, as)
 		}
 	}
 	return nil
@@ -192,16 +209,22 @@ func (self *move) adjudicateMovementPhase(r godip.Resolver) error {
 
 	convoyed := MustConvoy(r, self.targets[0])
 	if convoyed {
-		if len((ConvoyPathFinder{
+		path := (ConvoyPathFinder{
 			ConvoyPathFilter: ConvoyPathFilter{
 				Validator:              r,
 				Source:                 self.targets[0],
 				Destination:            self.targets[1],
 				ResolveConvoys:         true,
 				MinLengthAtDestination: 1,
-			}}).Any()) < 2 {
+			}}).Any()
+		if r.Explanation() != nil {
+			r.Explanation().SetConvoyPathCandidates(ConvoyPathCandidates(r, self.targets[0], self.targets[1]))
+		}
+		if len(path) < 2 {
+			r.Explanation().SetConvoyPath(nil)
 			return godip.ErrMissingConvoyPath
 		}
+		r.Explanation().SetConvoyPath(path)
 	}
 
 	if err := self.adjudicateAgainstCompetition(r, nil); err != nil {
@@ -212,35 +235,47 @@ func (self *move) adjudicateMovementPhase(r godip.Resolver) error {
 	// at destination
 	if victim, _, hasVictim := r.Unit(self.targets[1]); hasVictim {
 		forbiddenSupporter = &victim.Nation
-		attackStrength := MoveSupport(r, self.targets[0], self.targets[1], []godip.Nation{victim.Nation}) + 1
+		attackStrength := moveStrength(r, godip.AttackStrength, self.targets[0], self.targets[1], []godip.Nation{victim.Nation})
 		order, prov, _ := r.Order(self.targets[1])
-		godip.Logf("'%v' vs '%v': %v", self, order, attackStrength)
+		r.Logger().Logf("'%v' vs '%v': %v", self, order, attackStrength)
 		if order.Type() == godip.Move {
 			victimConvoyed := MustConvoy(r, order.Targets()[0])
 			if !convoyed && !victimConvoyed && order.Targets()[1].Super() == self.targets[0].Super() {
-				as := MoveSupport(r, order.Targets()[0], order.Targets()[1], []godip.Nation{unit.Nation}) + 1
-				godip.Logf("'%v' vs '%v': %v", order, self, as)
+				as := moveStrength(r, godip.DefendStrength, order.Targets()[0], order.Targets()[1], []godip.Nation{unit.Nation})
+				r.Logger().Logf("'%v' vs '%v': %v", order, self, as)
 				if victim.Nation == unit.Nation || as >= attackStrength {
 					return godip.ErrBounce{self.targets[1]}
 				}
 			} else {
-				godip.Logf("Esc(%v)", order.Targets()[0])
-				godip.Indent("  ")
+				r.Logger().Logf("Esc(%v)", order.Targets()[0])
+				r.Logger().Indent("  ")
 				if err := r.Resolve(prov); err == nil {
-					godip.DeIndent()
-					godip.Logf("Success")
+					r.Logger().DeIndent()
+					r.Logger().Logf("Success")
 					forbiddenSupporter = nil
 				} else {
-					godip.DeIndent()
-					godip.Logf("Failure: %v", err)
+					r.Logger().DeIndent()
+					r.Logger().Logf("Failure: %v", err)
+					r.Explanation().AddStrength(godip.Strength{
+						Type:     godip.HoldStrength,
+						Province: self.targets[1],
+						Strength: 1,
+					})
 					if victim.Nation == unit.Nation || 1 >= attackStrength {
 						return godip.ErrBounce{self.targets[1]}
 					}
 				}
 			}
 		} else {
-			hs := HoldSupport(r, self.targets[1]) + 1
-			godip.Logf("'%v': %v", order, hs)
+			holdSupporters := HoldSupporters(r, self.targets[1])
+			hs := len(holdSupporters) + 1
+			r.Explanation().AddStrength(godip.Strength{
+				Type:     godip.HoldStrength,
+				Province: self.targets[1],
+				Strength: hs,
+				Supports: holdSupporters,
+			})
+			r.Logger().Logf("'%v': %v", order, hs)
 			if victim.Nation == unit.Nation || hs >= attackStrength {
 				return godip.ErrBounce{self.targets[1]}
 			}
@@ -278,6 +313,9 @@ func (self *move) validateRetreatPhase(v godip.Validator) (godip.Nation, error)
 	if unit, self.targets[0], ok = v.Dislodged(self.targets[0]); !ok {
 		return "", godip.ErrMissingUnit
 	}
+	if missingCoast(v, unit.Type, self.targets[1]) {
+		return "", godip.ErrIllegalMove
+	}
 	var err error
 	if self.targets[1], err = AnyMovePossible(v, unit.Type, self.targets[0], self.targets[1], unit.Type == godip.Army, false, false); err != nil {
 		return "", godip.ErrIllegalMove
@@ -291,6 +329,12 @@ func (self *move) validateRetreatPhase(v godip.Validator) (godip.Nation, error)
 	return unit.Nation, nil
 }
 
+// missingCoast returns whether a unit of unitType moving or retreating to dst has to give the coast, because the rules
+// are strict about coasts and dst has more than one.
+func missingCoast(v godip.Validator, unitType godip.UnitType, dst godip.Province) bool {
+	return v.Rules().StrictCoasts && unitType == godip.Fleet && dst.Super() == dst && len(v.Graph().Coasts(dst)) > 1
+}
+
 func (self *move) validateMovementPhase(v godip.Validator) (godip.Nation, error) {
 	if !v.Graph().Has(self.targets[0]) {
 		return "", godip.ErrInvalidSource
@@ -306,6 +350,9 @@ func (self *move) validateMovementPhase(v godip.Validator) (godip.Nation, error)
 	if unit, self.targets[0], ok = v.Unit(self.targets[0]); !ok {
 		return "", godip.ErrMissingUnit
 	}
+	if missingCoast(v, unit.Type, self.targets[1]) {
+		return "", godip.ErrIllegalMove
+	}
 	var err error
 	if self.targets[1], err = AnyMovePossible(v, unit.Type, self.targets[0], self.targets[1], unit.Type == godip.Army, true, false); err != nil {
 		return "", err
@@ -416,7 +463,14 @@ func (self *move) Execute(state godip.State) {
 MoveSupport returns the successful supports of movement from src to dst, discounting the nations in forbiddenSupports.
 */
 func MoveSupport(r godip.Resolver, src, dst godip.Province, forbiddenSupports []godip.Nation) int {
-	_, supports, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
+	return len(MoveSupporters(r, src, dst, forbiddenSupports))
+}
+
+/*
+MoveSupporters returns the provinces of the successful supports of movement from src to dst, discounting the nations in forbiddenSupports.
+*/
+func MoveSupporters(r godip.Resolver, src, dst godip.Province, forbiddenSupports []godip.Nation) []godip.Province {
+	supporters, _, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
 		if o != nil && u != nil {
 			if o.Type() == godip.Support && len(o.Targets()) == 3 && o.Targets()[1].Contains(src) && o.Targets()[2].Contains(dst) {
 				for _, ban := range forbiddenSupports {
@@ -431,7 +485,20 @@ func MoveSupport(r godip.Resolver, src, dst godip.Province, forbiddenSupports []
 		}
 		return false
 	})
-	return len(supports)
+	return supporters
+}
+
+// moveStrength returns the strength of the movement from src to dst discounting the nations in forbiddenSupports, and adds it to the explanation of the order being adjudicated.
+func moveStrength(r godip.Resolver, typ godip.StrengthType, src, dst godip.Province, forbiddenSupports []godip.Nation) int {
+	supporters := MoveSupporters(r, src, dst, forbiddenSupports)
+	r.Explanation().AddStrength(godip.Strength{
+		Type:     typ,
+		Province: src,
+		Target:   dst,
+		Strength: len(supporters) + 1,
+		Supports: supporters,
+	})
+	return len(supporters) + 1
 }
 
 func HasEdge(v godip.Validator, typ godip.UnitType, src, dst godip.Province) bool {
@@ -448,6 +515,13 @@ func HasEdge(v godip.Validator, typ godip.UnitType, src, dst godip.Province) boo
 // routes to be considered, and these must avoid the province noConvoy (if given).
 func PossibleMovesUnit(v godip.Validator, unitType godip.UnitType, start godip.Province, reverse bool, allowConvoy bool, noConvoy *godip.Province) (result []godip.Province) {
 	defer v.Profile("PossibleMovesUnit", time.Now())
+	// Only fleets convoy, so without a fleet in noConvoy the result is the same as without noConvoy, and can be shared
+	// by everyone asking.
+	if noConvoy != nil {
+		if unit, _, found := v.Unit(*noConvoy); !found || unit.Type != godip.Fleet {
+			noConvoy = nil
+		}
+	}
 	noConvoyStr := ""
 	if noConvoy != nil {
 		noConvoyStr = string(*noConvoy)
@@ -588,3 +662,14 @@ func movePossible(v godip.Validator, typ godip.UnitType, src, dst godip.Province
 	}
 	return nil
 }
+
+func copyFlags(flags map[godip.Flag]bool) map[godip.Flag]bool {
+	if flags == nil {
+		return nil
+	}
+	result := make(map[godip.Flag]bool, len(flags))
+	for flag, value := range flags {
+		result[flag] = value
+	}
+	return result
+}
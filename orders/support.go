@@ -53,6 +53,10 @@ func (self *support) String() string {
 	return fmt.Sprintf("%v %v %v", self.targets[0], godip.Support, self.targets[1:])
 }
 
+func (self *support) Bits() []string {
+	return []string{string(self.targets[0]), string(godip.Support), string(self.targets[1]), string(self.targets[len(self.targets)-1])}
+}
+
 func (self *support) At() time.Time {
 	return time.Now()
 }
@@ -69,6 +73,12 @@ func (self *support) Targets() []godip.Province {
 	return self.targets
 }
 
+func (self *support) Clone() godip.Adjudicator {
+	return &support{
+		targets: append([]godip.Province{}, self.targets...),
+	}
+}
+
 func (self *support) Adjudicate(r godip.Resolver) error {
 	unit, _, _ := r.Unit(self.targets[0])
 	if breaks, _, _ := r.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
@@ -104,7 +114,8 @@ func (self *support) Adjudicate(r godip.Resolver) error {
 		}
 		return false
 	}); len(breaks) > 0 {
-		godip.Logf("%v: broken by: %v", self, breaks)
+		r.Logger().Logf("%v: broken by: %v", self, breaks)
+		r.Explanation().SetCutBy(breaks)
 		return godip.ErrSupportBroken{breaks[0]}
 	}
 
@@ -116,19 +127,20 @@ func (self *support) Adjudicate(r godip.Resolver) error {
 			u.Nation != unit.Nation && // not from ourselves
 			r.Resolve(p) == nil // and it succeeded
 	}); len(dislodgers) > 0 {
-		godip.Logf("%v: dislodged by: %v", self, dislodgers)
+		r.Logger().Logf("%v: dislodged by: %v", self, dislodgers)
+		r.Explanation().SetDislodgedBy(dislodgers)
 		return godip.ErrSupportBroken{dislodgers[0]}
 	}
 
 	order, _, found := r.Order(self.targets[1])
 	if len(self.targets) == 2 {
 		if found && order.Type() == godip.Move {
-			godip.Logf("%v: supported unit not holding still", self)
+			r.Logger().Logf("%v: supported unit not holding still", self)
 			return godip.ErrInvalidSupporteeOrder
 		}
 	} else {
 		if !found || order.Type() != godip.Move || order.Targets()[1].Super() != self.targets[2].Super() {
-			godip.Logf("%v: support unit not moving from %v to %v", self, self.targets[1].Super(), self.targets[2].Super())
+			r.Logger().Logf("%v: support unit not moving from %v to %v", self, self.targets[1].Super(), self.targets[2].Super())
 			return godip.ErrInvalidSupporteeOrder
 		}
 	}
@@ -273,6 +285,15 @@ func (self *support) Validate(v godip.Validator) (godip.Nation, error) {
 		if _, err := AnyMovePossible(v, supported.Type, self.targets[1], self.targets[2], true, true, false); err != nil {
 			return "", godip.ErrIllegalSupportMove
 		}
+		if v.Rules().ReachableSupportCoasts && unit.Type == godip.Fleet {
+			if supportedOrder, _, found := v.Order(self.targets[1]); found && supportedOrder.Type() == godip.Move && supportedOrder.Targets()[1].Super() == self.targets[2].Super() {
+				if dst, err := AnyMovePossible(v, supported.Type, self.targets[1], supportedOrder.Targets()[1], false, false, false); err == nil && dst.Super() != dst {
+					if err := movePossible(v, unit.Type, self.targets[0], dst, false, false); err != nil {
+						return "", godip.ErrIllegalSupportDestination
+					}
+				}
+			}
+		}
 	}
 	return unit.Nation, nil
 }
@@ -316,7 +316,7 @@ func SortedUnits(s godip.State, n godip.Nation) (result []godip.Province, err er
 		return
 	}
 	sort.Sort(provs)
-	godip.Logf("Sorted units for %v is %v", n, provs)
+	s.Logger().Logf("Sorted units for %v is %v", n, provs)
 	result = provs.provinces
 	return
 }
@@ -337,7 +337,7 @@ func (self *Phase) PostProcess(s godip.State) (err error) {
 		for prov, _ := range s.Dislodgeds() {
 			s.RemoveDislodged(prov)
 			s.ForceDisband(prov)
-			godip.Logf("Removing %v since it didn't retreat", prov)
+			s.Logger().Logf("Removing %v since it didn't retreat", prov)
 		}
 		s.ClearDislodgers()
 		s.ClearBounces()
@@ -353,7 +353,7 @@ func (self *Phase) PostProcess(s godip.State) (err error) {
 				for _, prov := range su {
 					s.RemoveUnit(prov)
 					s.ForceDisband(prov)
-					godip.Logf("Removing %v since it wasn't disbanded by order", prov)
+					s.Logger().Logf("Removing %v since it wasn't disbanded by order", prov)
 				}
 			}
 		}
@@ -363,7 +363,7 @@ func (self *Phase) PostProcess(s godip.State) (err error) {
 			for edge, _ := range s.Graph().Edges(prov, false) {
 				if _, _, ok := s.Unit(edge); !ok && !s.Bounce(prov, edge) {
 					if orders.HasEdge(s, unit.Type, prov, edge) {
-						godip.Logf("%v can retreat to %v", prov, edge)
+						s.Logger().Logf("%v can retreat to %v", prov, edge)
 						hasRetreat = true
 						break
 					}
@@ -372,7 +372,7 @@ func (self *Phase) PostProcess(s godip.State) (err error) {
 			if !hasRetreat {
 				s.RemoveDislodged(prov)
 				s.ForceDisband(prov)
-				godip.Logf("Removing %v since it has no retreat", prov)
+				s.Logger().Logf("Removing %v since it has no retreat", prov)
 			}
 		}
 	}
@@ -380,7 +380,7 @@ func (self *Phase) PostProcess(s godip.State) (err error) {
 		s.Find(func(p godip.Province, o godip.Order, u *godip.Unit) bool {
 			if u != nil {
 				if s.Graph().SC(p) != nil {
-					godip.Logf("%v now belongs to %v", p.Super(), u.Nation)
+					s.Logger().Logf("%v now belongs to %v", p.Super(), u.Nation)
 					s.SetSC(p.Super(), u.Nation)
 				}
 			}
@@ -0,0 +1,323 @@
+/*
+Package randomplay plays games of a variant by sampling the orders of every nation from the options of each phase,
+which produces game records exercising the rules of new variants without needing real games.
+*/
+package randomplay
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/gamerecord"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+// Config controls how games are played. The probabilities are between 0 and 1.
+type Config struct {
+	// Seed seeds the random choices, so that the same seed and config play the same game.
+	Seed int64
+	// Aggression is the probability that a unit moves instead of holding or supporting, and that a unit not moving
+	// supports a move of its own nation instead of a random unit.
+	Aggression float64
+	// Convoys is the probability that an army able to move via convoy does, with the fleets of its own nation able to
+	// convoy it ordered to.
+	Convoys float64
+	// BuildAnywhere is the probability that a build is placed in a supply center that isn't a home center, when the
+	// variant allows it.
+	BuildAnywhere float64
+	// Years is the max number of years to play, if no nation has won and the variant doesn't end the game earlier.
+	Years int
+}
+
+// DefaultConfig is a config playing moderately aggressive games of at most 20 years.
+var DefaultConfig = Config{
+	Aggression:    0.6,
+	Convoys:       0.2,
+	BuildAnywhere: 0.5,
+	Years:         20,
+}
+
+// option is one path through an options tree, i.e. one order.
+type option struct {
+	typ godip.OrderType
+	// bits are the parts of the order, as accepted by orders.Parser.Parse.
+	bits []string
+}
+
+func (self option) src() godip.Province {
+	return godip.Province(self.bits[0])
+}
+
+// target returns the last province of the order, e.g. the destination of a move.
+func (self option) target() godip.Province {
+	return godip.Province(self.bits[len(self.bits)-1])
+}
+
+/*
+flatten returns the orders in an options tree of one province and order type.
+
+The orders are sorted, since the trees are maps and random choices among them must be repeatable.
+*/
+func flatten(typ godip.OrderType, tree godip.Options, path []string, src string) (result []option) {
+	if len(tree) == 0 {
+		return []option{{typ: typ, bits: append([]string{src, string(typ)}, path...)}}
+	}
+	keys := make([]godip.OptionValue, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	for _, key := range keys {
+		value := key
+		if filtered, ok := value.(godip.FilteredOptionValue); ok {
+			value = filtered.Value
+		}
+		if prov, ok := value.(godip.SrcProvince); ok {
+			result = append(result, flatten(typ, tree[key], path, string(prov))...)
+		} else {
+			result = append(result, flatten(typ, tree[key], append(append([]string{}, path...), fmt.Sprint(value)), src)...)
+		}
+	}
+	return result
+}
+
+// player chooses the orders of one nation in one phase.
+type player struct {
+	variant common.Variant
+	config  Config
+	rand    *rand.Rand
+	s       *state.State
+	nation  godip.Nation
+	// options are the orders available for each province, by order type.
+	options map[godip.Province]map[godip.OrderType][]option
+	// provinces are the provinces with options, sorted.
+	provinces []godip.Province
+	chosen    map[godip.Province]option
+}
+
+func newPlayer(play *play, nation godip.Nation, options godip.Options) *player {
+	result := &player{
+		variant: play.variant,
+		config:  play.config,
+		rand:    play.rand,
+		s:       play.s,
+		nation:  nation,
+		options: map[godip.Province]map[godip.OrderType][]option{},
+		chosen:  map[godip.Province]option{},
+	}
+	for key, types := range options {
+		prov := key.(godip.Province)
+		result.provinces = append(result.provinces, prov)
+		result.options[prov] = map[godip.OrderType][]option{}
+		for typ, tree := range types {
+			result.options[prov][typ.(godip.OrderType)] = flatten(typ.(godip.OrderType), tree, nil, "")
+		}
+	}
+	sort.Slice(result.provinces, func(i, j int) bool {
+		return result.provinces[i] < result.provinces[j]
+	})
+	return result
+}
+
+func (self *player) chance(probability float64) bool {
+	return self.rand.Float64() < probability
+}
+
+func (self *player) pick(options []option) option {
+	return options[self.rand.Intn(len(options))]
+}
+
+func (self *player) shuffledProvinces() []godip.Province {
+	result := append([]godip.Province{}, self.provinces...)
+	self.rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+func (self *player) movement() {
+	provs := self.shuffledProvinces()
+	for _, prov := range provs {
+		if _, found := self.chosen[prov]; found {
+			continue
+		}
+		if convoyed := self.options[prov][godip.MoveViaConvoy]; len(convoyed) > 0 && self.chance(self.config.Convoys) {
+			move := self.pick(convoyed)
+			self.chosen[prov] = move
+			for _, fleet := range self.provinces {
+				if _, found := self.chosen[fleet]; found {
+					continue
+				}
+				for _, convoy := range self.options[fleet][godip.Convoy] {
+					if godip.Province(convoy.bits[2]) == move.src() && convoy.target() == move.target() {
+						self.chosen[fleet] = convoy
+						break
+					}
+				}
+			}
+		} else if moves := self.options[prov][godip.Move]; len(moves) > 0 && self.chance(self.config.Aggression) {
+			self.chosen[prov] = self.pick(moves)
+		}
+	}
+	for _, prov := range provs {
+		if _, found := self.chosen[prov]; found {
+			continue
+		}
+		supports := self.options[prov][godip.Support]
+		if self.chance(self.config.Aggression) {
+			helpful := []option{}
+			for _, support := range supports {
+				if supported, found := self.chosen[godip.Province(support.bits[2]).Super()]; found && supported.target().Super() == support.target().Super() {
+					helpful = append(helpful, support)
+				}
+			}
+			if len(helpful) > 0 {
+				self.chosen[prov] = self.pick(helpful)
+				continue
+			}
+		}
+		if len(supports) > 0 && self.chance(0.5) {
+			self.chosen[prov] = self.pick(supports)
+		} else if holds := self.options[prov][godip.Hold]; len(holds) > 0 {
+			self.chosen[prov] = self.pick(holds)
+		}
+	}
+}
+
+func (self *player) retreat() {
+	for _, prov := range self.provinces {
+		all := append(append([]option{}, self.options[prov][godip.Move]...), self.options[prov][godip.Disband]...)
+		if len(all) > 0 {
+			self.chosen[prov] = self.pick(all)
+		}
+	}
+}
+
+func (self *player) isHome(prov godip.Province) bool {
+	owner := self.s.Graph().SC(prov)
+	return owner != nil && *owner == self.nation
+}
+
+func (self *player) adjustment() {
+	_, _, balance := orders.AdjustmentStatus(self.s, self.nation)
+	provs := self.shuffledProvinces()
+	if balance < 0 {
+		for _, prov := range provs {
+			if disbands := self.options[prov][godip.Disband]; len(disbands) > 0 && len(self.chosen) < -balance {
+				self.chosen[prov] = self.pick(disbands)
+			}
+		}
+		return
+	}
+	home, away := []godip.Province{}, []godip.Province{}
+	for _, prov := range provs {
+		if len(self.options[prov][godip.Build]) == 0 {
+			continue
+		}
+		if self.isHome(prov) {
+			home = append(home, prov)
+		} else {
+			away = append(away, prov)
+		}
+	}
+	built := map[godip.Province]bool{}
+	for len(self.chosen) < balance && len(home)+len(away) > 0 {
+		var prov godip.Province
+		if len(away) > 0 && (len(home) == 0 || self.chance(self.config.BuildAnywhere)) {
+			prov, away = away[0], away[1:]
+		} else {
+			prov, home = home[0], home[1:]
+		}
+		if built[prov.Super()] {
+			continue
+		}
+		built[prov.Super()] = true
+		self.chosen[prov] = self.pick(self.options[prov][godip.Build])
+	}
+}
+
+// orders returns the chosen orders, parsed by the parser of the variant.
+func (self *player) orders() (map[godip.Province]godip.Adjudicator, error) {
+	result := map[godip.Province]godip.Adjudicator{}
+	for _, option := range self.chosen {
+		order, err := self.variant.Parser.Parse(option.bits)
+		if err != nil {
+			return nil, err
+		}
+		result[option.src()] = order
+	}
+	return result, nil
+}
+
+type play struct {
+	variant common.Variant
+	config  Config
+	rand    *rand.Rand
+	s       *state.State
+}
+
+func (self *play) orders() (map[godip.Province]godip.Adjudicator, error) {
+	result := map[godip.Province]godip.Adjudicator{}
+	options := self.s.AllOptions()
+	for _, nation := range self.variant.Nations {
+		player := newPlayer(self, nation, options[nation])
+		switch self.s.Phase().Type() {
+		case godip.Movement:
+			player.movement()
+		case godip.Retreat:
+			player.retreat()
+		case godip.Adjustment:
+			player.adjustment()
+		}
+		nationOrders, err := player.orders()
+		if err != nil {
+			return nil, err
+		}
+		for prov, order := range nationOrders {
+			result[prov] = order
+		}
+	}
+	return result, nil
+}
+
+/*
+Play plays a game of variant until a nation wins, the variant ends it, or config.Years have been played, and returns
+the record of it.
+
+Phases without orders, e.g. retreat phases without dislodged units, are left out of the record.
+*/
+func Play(variant common.Variant, config Config) (*gamerecord.Record, error) {
+	s, err := variant.Start()
+	if err != nil {
+		return nil, err
+	}
+	p := &play{
+		variant: variant,
+		config:  config,
+		rand:    rand.New(rand.NewSource(config.Seed)),
+		s:       s,
+	}
+	states := []*state.State{}
+	lastYear := s.Phase().Year() + config.Years
+	for !variant.Result(s).Ended() && s.Phase().Year() < lastYear {
+		ords, err := p.orders()
+		if err != nil {
+			return nil, err
+		}
+		s.SetOrders(ords)
+		if len(ords) > 0 {
+			states = append(states, s.Clone())
+		}
+		if err := s.Next(); err != nil {
+			return nil, err
+		}
+	}
+	states = append(states, s.Clone())
+	return gamerecord.FromStates(states), nil
+}
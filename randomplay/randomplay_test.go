@@ -0,0 +1,50 @@
+package randomplay
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zond/godip/gamerecord"
+	"github.com/zond/godip/variants"
+)
+
+func writeRecord(t *testing.T, record *gamerecord.Record) []byte {
+	buf := &bytes.Buffer{}
+	if err := record.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPlay(t *testing.T) {
+	config := DefaultConfig
+	config.Seed = 7
+	config.Years = 4
+	for _, variant := range variants.OrderedVariants {
+		record, err := Play(variant, config)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		written := writeRecord(t, record)
+		reread, err := gamerecord.Read(bytes.NewReader(written))
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		states, err := reread.States(variant)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		for index, phase := range reread.Phases {
+			for _, err := range phase.Check(states[index]) {
+				t.Errorf("%v: %v: %v", variant.Name, phase, err)
+			}
+		}
+		again, err := Play(variant, config)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		if !bytes.Equal(written, writeRecord(t, again)) {
+			t.Errorf("%v: Wanted the same game from the same seed", variant.Name)
+		}
+	}
+}
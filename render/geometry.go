@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type vector struct {
+	dx float64
+	dy float64
+}
+
+func (v vector) length() float64 {
+	return math.Sqrt(v.dx*v.dx + v.dy*v.dy)
+}
+
+func (a vector) add(b vector) vector {
+	return vector{a.dx + b.dx, a.dy + b.dy}
+}
+
+func (v vector) mul(m float64) vector {
+	return vector{v.dx * m, v.dy * m}
+}
+
+func (v vector) div(d float64) vector {
+	return vector{v.dx / d, v.dy / d}
+}
+
+func (v vector) dir() vector {
+	return v.div(v.length())
+}
+
+func (v vector) orth() vector {
+	return vector{-v.dy, v.dx}.dir()
+}
+
+type coordinates struct {
+	x float64
+	y float64
+}
+
+func (c coordinates) add(v vector) coordinates {
+	return coordinates{c.x + v.dx, c.y + v.dy}
+}
+
+func (c coordinates) sub(v vector) coordinates {
+	return coordinates{c.x - v.dx, c.y - v.dy}
+}
+
+func (a coordinates) to(b coordinates) vector {
+	return vector{b.x - a.x, b.y - a.y}
+}
+
+func (a coordinates) middle(b coordinates) coordinates {
+	return coordinates{(a.x + b.x) / 2, (a.y + b.y) / 2}
+}
+
+func (c coordinates) String() string {
+	return toStr(c.x) + "," + toStr(c.y)
+}
+
+func toStr(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+/*
+arrowPath returns the path data of an arrow from start to end, width wide, that stops a bit short of both ends to leave
+room for the units there.
+
+The shaft of the arrow bends slightly around the middle of start and end, so arrows in opposite directions between the
+same provinces don't hide each other.
+*/
+func arrowPath(start coordinates, end coordinates, width float64) string {
+	middle := start.middle(end)
+
+	boundF := width / 2
+	headF1 := boundF * 3
+	headF2 := boundF * 6
+	spacer := boundF * 2
+	part1 := start.to(middle)
+	part2 := middle.to(end)
+	start0 := start.add(part1.dir().mul(spacer)).add(part1.orth().mul(boundF))
+	start1 := start.add(part1.dir().mul(spacer)).sub(part1.orth().mul(boundF))
+	sumOrth := part1.orth().add(part2.orth())
+	avgOrth := sumOrth.div(sumOrth.length())
+	control0 := middle.add(avgOrth.mul(boundF))
+	control1 := middle.sub(avgOrth.mul(boundF))
+	end0 := end.sub(part2.dir().mul(spacer + headF2)).add(part2.orth().mul(boundF))
+	end1 := end.sub(part2.dir().mul(spacer + headF2)).sub(part2.orth().mul(boundF))
+	end3 := end.sub(part2.dir().mul(spacer))
+	head0 := end0.add(part2.orth().mul(headF1))
+	head1 := end1.sub(part2.orth().mul(headF1))
+
+	d := []string{
+		"M", start0.String(),
+		"C", control0.String(), control0.String(), end0.String(),
+		"L", head0.String(),
+		"L", end3.String(),
+		"L", head1.String(),
+		"L", end1.String(),
+		"C", control1.String(), control1.String(), start1.String(),
+		"z",
+	}
+	return strings.Join(d, " ")
+}
+
+// crossPath returns the path data of an X, size wide, centered at c.
+func crossPath(c coordinates, size float64) string {
+	half := size / 2
+	return fmt.Sprintf("M %v L %v M %v L %v",
+		c.add(vector{-half, -half}), c.add(vector{half, half}),
+		c.add(vector{-half, half}), c.add(vector{half, -half}))
+}
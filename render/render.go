@@ -0,0 +1,422 @@
+/*
+Package render draws the positions of games as svg maps, for clients that can't run the map scripts themselves.
+
+The map of the variant (variant.SVGMap) is filled in the same way the interactive clients do it: the supply centers
+are colored by owner in the "provinces" layer, the units of the variant (variant.SVGUnits) are placed at the unit
+points of variant.ProvinceGeometries in the "units" layer, and the orders are drawn in the "orders" layer.
+*/
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants/common"
+)
+
+// DefaultColors are the colors of the nations of variants without NationColors, in the order of variant.Nations.
+var DefaultColors = []string{
+	"#2196F3",
+	"#F44336",
+	"#4CAF50",
+	"#FFEB3B",
+	"#9C27B0",
+	"#FF9800",
+	"#00BCD4",
+	"#795548",
+	"#E91E63",
+	"#8BC34A",
+	"#3F51B5",
+	"#CDDC39",
+	"#009688",
+	"#607D8B",
+	"#673AB7",
+	"#FFC107",
+}
+
+const (
+	neutralColor = "#9E9E9E"
+	failedColor  = "#FF0000"
+	// ownerOpacity is the opacity of the owner colors of the supply centers, to keep the map visible through them.
+	ownerOpacity = "0.4"
+)
+
+// Colors returns the color of each nation of variant, from variant.NationColors if it has them.
+func Colors(variant common.Variant) map[godip.Nation]string {
+	result := map[godip.Nation]string{}
+	for index, nation := range variant.Nations {
+		if color, found := variant.NationColors[nation]; found {
+			result[nation] = color
+		} else {
+			result[nation] = DefaultColors[index%len(DefaultColors)]
+		}
+	}
+	return result
+}
+
+// unitGraphic is the svg of a unit type, to be embedded in the map once for each unit.
+type unitGraphic struct {
+	// b is the root element of the svg.
+	b    []byte
+	root element
+	// colored are the elements ("body" and "hull") filled with the color of the nation of the unit.
+	colored []element
+	width   float64
+	height  float64
+}
+
+func newUnitGraphic(b []byte) (*unitGraphic, error) {
+	var root *element
+	colored := []element{}
+	end, err := scan(b, func(el element) {
+		if el.depth == 0 {
+			root = &el
+		} else if id := el.attr("id"); id == "body" || id == "hull" {
+			colored = append(colored, el)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &unitGraphic{
+		b:    b[root.start:end],
+		root: *root,
+	}
+	result.root.start, result.root.end = 0, root.end-root.start
+	for _, el := range colored {
+		el.start, el.end = el.start-root.start, el.end-root.start
+		result.colored = append(result.colored, el)
+	}
+	if result.width, err = strconv.ParseFloat(strings.TrimSuffix(root.attr("width"), "px"), 64); err != nil {
+		return nil, fmt.Errorf("Unparseable unit width %q: %v", root.attr("width"), err)
+	}
+	if result.height, err = strconv.ParseFloat(strings.TrimSuffix(root.attr("height"), "px"), 64); err != nil {
+		return nil, fmt.Errorf("Unparseable unit height %q: %v", root.attr("height"), err)
+	}
+	return result, nil
+}
+
+// draw returns the unit as a nested svg element centered at c.
+func (self *unitGraphic) draw(c coordinates, color string, class string, opacity string) []byte {
+	tag := self.root.tag(self.b)
+	tag = setAttr(tag, "x", toStr(c.x-self.width/2))
+	tag = setAttr(tag, "y", toStr(c.y-self.height/2))
+	tag = setAttr(tag, "class", class)
+	if opacity != "" {
+		tag = setAttr(tag, "opacity", opacity)
+	}
+	edits := []edit{{start: self.root.start, end: self.root.end, text: tag}}
+	for _, el := range self.colored {
+		edits = append(edits, edit{
+			start: el.start,
+			end:   el.end,
+			text:  setStyle(el.tag(self.b), el.attr("style"), "fill", color),
+		})
+	}
+	return apply(self.b, edits)
+}
+
+type board struct {
+	variant    common.Variant
+	s          *state.State
+	colors     map[godip.Nation]string
+	geometries map[godip.Province]common.ProvinceGeometry
+	// toLayers are the transforms from the coordinates of the map to the coordinates of the content of the layers.
+	toLayers     map[string]common.Transform
+	unitGraphics map[godip.UnitType]*unitGraphic
+}
+
+func (self *board) color(nation godip.Nation) string {
+	if color, found := self.colors[nation]; found {
+		return color
+	}
+	return neutralColor
+}
+
+// center returns where a unit in prov is placed, in the coordinates of the content of layer.
+func (self *board) center(layer string, prov godip.Province) (coordinates, bool) {
+	geometry, found := self.geometries[prov]
+	if !found {
+		return coordinates{}, false
+	}
+	p := self.toLayers[layer].Apply(geometry.Unit)
+	return coordinates{p.X, p.Y}, true
+}
+
+func (self *board) unitGraphic(typ godip.UnitType) (*unitGraphic, error) {
+	if graphic, found := self.unitGraphics[typ]; found {
+		return graphic, nil
+	}
+	svg, found := self.variant.SVGUnits[typ]
+	if !found {
+		return nil, fmt.Errorf("%v has no svg for %v", self.variant.Name, typ)
+	}
+	b, err := svg()
+	if err != nil {
+		return nil, err
+	}
+	graphic, err := newUnitGraphic(b)
+	if err != nil {
+		return nil, fmt.Errorf("%v %v svg: %v", self.variant.Name, typ, err)
+	}
+	self.unitGraphics[typ] = graphic
+	return graphic, nil
+}
+
+func sortedProvinces(units map[godip.Province]godip.Unit) []godip.Province {
+	result := make([]godip.Province, 0, len(units))
+	for prov := range units {
+		result = append(result, prov)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// units returns the units and dislodged units of the state, the dislodged ones shifted and faded to not hide the units
+// that dislodged them.
+func (self *board) units() ([]byte, error) {
+	result := &bytes.Buffer{}
+	for _, dislodged := range []bool{false, true} {
+		units := self.s.Units()
+		class, opacity := "unit", ""
+		if dislodged {
+			units = self.s.Dislodgeds()
+			class, opacity = "unit dislodged", "0.7"
+		}
+		for _, prov := range sortedProvinces(units) {
+			unit := units[prov]
+			c, found := self.center("units", prov)
+			if !found {
+				continue
+			}
+			graphic, err := self.unitGraphic(unit.Type)
+			if err != nil {
+				return nil, err
+			}
+			if dislodged {
+				c = c.add(vector{graphic.width / 3, graphic.height / 3})
+			}
+			result.Write(graphic.draw(c, self.color(unit.Nation), class, opacity))
+		}
+	}
+	return result.Bytes(), nil
+}
+
+// nation returns the nation giving order for prov.
+func (self *board) nation(prov godip.Province, order godip.Order) godip.Nation {
+	if order.Type() == godip.Build {
+		if nation, _, ok := self.s.SupplyCenter(prov); ok {
+			return nation
+		}
+	} else if self.s.Phase() != nil && self.s.Phase().Type() == godip.Retreat {
+		if unit, _, ok := self.s.Dislodged(prov); ok {
+			return unit.Nation
+		}
+	} else if unit, _, ok := self.s.Unit(prov); ok {
+		return unit.Nation
+	}
+	return godip.Neutral
+}
+
+func arrow(start, end coordinates, class string, width float64, style string) string {
+	if start == end {
+		return ""
+	}
+	return fmt.Sprintf(`<path class="order %v" d="%v" style="%v"/>`, class, arrowPath(start, end, width), style)
+}
+
+func ring(c coordinates, class string, style string) string {
+	return fmt.Sprintf(`<circle class="order %v" cx="%v" cy="%v" r="20" style="%v"/>`, class, toStr(c.x), toStr(c.y), style)
+}
+
+/*
+order returns the drawing of order, given for prov, and where to mark it if it failed.
+
+The map key prov is used as the source of the order, since validating orders replaces their source with the coast of
+the unit, or with nothing if there is no unit.
+*/
+func (self *board) order(prov godip.Province, order godip.Order) (string, coordinates, error) {
+	color := self.color(self.nation(prov, order))
+	moveStyle := fmt.Sprintf("fill:%v;fill-opacity:0.9;stroke:#000000;stroke-width:0.5", color)
+	supportStyle := fmt.Sprintf("fill:%v;fill-opacity:0.6;stroke:#000000;stroke-width:0.5;stroke-dasharray:3,2", color)
+	ringStyle := fmt.Sprintf("fill:none;stroke:%v;stroke-width:4", color)
+	src, found := self.center("orders", prov)
+	if !found {
+		return "", coordinates{}, nil
+	}
+	targets := order.Targets()
+	center := func(index int) (coordinates, bool) {
+		if index >= len(targets) {
+			return coordinates{}, false
+		}
+		return self.center("orders", targets[index])
+	}
+	switch order.Type() {
+	case godip.Move:
+		if dst, found := center(1); found {
+			return arrow(src, dst, "move", 6, moveStyle), src.middle(dst), nil
+		}
+	case godip.Support, godip.Convoy:
+		class := "support"
+		if order.Type() == godip.Convoy {
+			class = "convoy"
+		}
+		from, found := center(1)
+		if !found {
+			break
+		}
+		to, found := center(2)
+		if !found || len(targets) < 3 || targets[1] == targets[2] {
+			return arrow(src, from, class, 3, supportStyle) + ring(from, class, ringStyle), src.middle(from), nil
+		}
+		middle := from.middle(to)
+		return arrow(src, middle, class, 3, supportStyle), src.middle(middle), nil
+	case godip.Build:
+		encoded, err := orders.Encode(order)
+		if err != nil {
+			return "", coordinates{}, err
+		}
+		graphic, err := self.unitGraphic(godip.UnitType(encoded.Bits[2]))
+		if err != nil {
+			return "", coordinates{}, err
+		}
+		return ring(src, "build", ringStyle) + string(graphic.draw(src, color, "unit build", "0.5")), src, nil
+	case godip.Disband:
+		return ring(src, "disband", ringStyle) + fmt.Sprintf(`<path class="order disband" d="%v" style="fill:none;stroke:%v;stroke-width:4"/>`, crossPath(src, 28), color), src, nil
+	}
+	return "", src, nil
+}
+
+// orders returns the drawings of the orders, with the failed ones, those with a non nil resolution, crossed out.
+func (self *board) orders(ords map[godip.Province]godip.Adjudicator, resolutions map[godip.Province]error) ([]byte, error) {
+	provs := make([]godip.Province, 0, len(ords))
+	for prov := range ords {
+		provs = append(provs, prov)
+	}
+	sort.Slice(provs, func(i, j int) bool {
+		return provs[i] < provs[j]
+	})
+	result := &bytes.Buffer{}
+	failed := &bytes.Buffer{}
+	for _, prov := range provs {
+		drawing, mark, err := self.order(prov, ords[prov])
+		if err != nil {
+			return nil, err
+		}
+		if drawing == "" {
+			continue
+		}
+		result.WriteString(drawing)
+		if resolutions[prov] != nil {
+			fmt.Fprintf(failed, `<path class="failed" d="%v" style="fill:none;stroke:%v;stroke-width:5"/>`, crossPath(mark, 16), failedColor)
+		}
+	}
+	result.Write(failed.Bytes())
+	return result.Bytes(), nil
+}
+
+/*
+Render returns the map of variant with the units, dislodged units and supply center owners of s.
+
+If orders are given, they are drawn too, and if resolutions are given, the orders with a non nil resolution are crossed
+out. To draw the orders of a phase with their results, render a clone of the state taken before calling Next, with its
+orders and the resolutions of the state after Next.
+
+Holds are not drawn, and orders to provinces missing in the graph of the variant are left out.
+*/
+func Render(variant common.Variant, s *state.State, orders map[godip.Province]godip.Adjudicator, resolutions map[godip.Province]error) ([]byte, error) {
+	b, err := variant.SVGMap()
+	if err != nil {
+		return nil, err
+	}
+	geometries, err := variant.ProvinceGeometries()
+	if err != nil {
+		return nil, err
+	}
+	board := &board{
+		variant:      variant,
+		s:            s,
+		colors:       Colors(variant),
+		geometries:   geometries,
+		toLayers:     map[string]common.Transform{},
+		unitGraphics: map[godip.UnitType]*unitGraphic{},
+	}
+	layers := map[string]*element{}
+	provinceElements := []element{}
+	var provincesLayer *element
+	// transforms are the transforms from the content of the elements the scanned element is inside to the map.
+	transforms := []common.Transform{}
+	var transformErr error
+	if _, err := scan(b, func(el element) {
+		transform := common.Identity
+		if el.depth > 0 {
+			transform = transforms[el.depth-1]
+		}
+		if attr := el.attr("transform"); attr != "" {
+			own, err := common.ParseTransform(attr)
+			if err != nil && transformErr == nil {
+				transformErr = fmt.Errorf("Unparseable transform of %v: %v", el.attr("id"), err)
+			}
+			transform = own.Then(transform)
+		}
+		transforms = append(transforms[:el.depth], transform)
+		id := el.attr("id")
+		if provincesLayer != nil && el.depth <= provincesLayer.depth {
+			provincesLayer = nil
+		}
+		switch {
+		case id == "provinces" || id == "units" || id == "orders":
+			layers[id] = &el
+			board.toLayers[id] = transform.Inverse()
+			if id == "provinces" {
+				provincesLayer = &el
+			}
+		case provincesLayer != nil && s.Graph().Has(godip.Province(id)):
+			provinceElements = append(provinceElements, el)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("%v map: %v", variant.Name, err)
+	}
+	if transformErr != nil {
+		return nil, fmt.Errorf("%v map: %v", variant.Name, transformErr)
+	}
+	for _, id := range []string{"provinces", "units", "orders"} {
+		if layers[id] == nil {
+			return nil, fmt.Errorf("%v map has no %q layer", variant.Name, id)
+		}
+	}
+
+	edits := []edit{{
+		start: layers["provinces"].start,
+		end:   layers["provinces"].end,
+		text:  setStyle(layers["provinces"].tag(b), layers["provinces"].attr("style"), "display", "inline"),
+	}}
+	supplyCenters := s.SupplyCenters()
+	for _, el := range provinceElements {
+		prov := godip.Province(el.attr("id"))
+		text := setStyle(el.tag(b), el.attr("style"), "opacity", "0")
+		if nation, found := supplyCenters[prov]; found && prov.Super() == prov {
+			text = setStyle(el.tag(b), el.attr("style"), "fill", board.color(nation), "fill-opacity", ownerOpacity)
+		}
+		edits = append(edits, edit{start: el.start, end: el.end, text: text})
+	}
+	units, err := board.units()
+	if err != nil {
+		return nil, err
+	}
+	edits = append(edits, content(b, *layers["units"], units))
+	drawnOrders, err := board.orders(orders, resolutions)
+	if err != nil {
+		return nil, err
+	}
+	edits = append(edits, content(b, *layers["orders"], drawnOrders))
+	return apply(b, edits), nil
+}
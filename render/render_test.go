@@ -0,0 +1,187 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/graph"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants"
+	"github.com/zond/godip/variants/classical"
+	"github.com/zond/godip/variants/common"
+)
+
+// drawn counts the elements of the rendered svg by class, and the fill of the province elements by id.
+type drawn struct {
+	classes map[string]int
+	fills   map[string]string
+}
+
+func parse(t *testing.T, b []byte) drawn {
+	result := drawn{
+		classes: map[string]int{},
+		fills:   map[string]string{},
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return result
+		} else if err != nil {
+			t.Fatalf("Unparseable svg: %v", err)
+		}
+		if el, ok := token.(xml.StartElement); ok {
+			for _, attr := range el.Attr {
+				switch attr.Name.Local {
+				case "class":
+					for _, class := range strings.Fields(attr.Value) {
+						result.classes[class]++
+					}
+				case "style":
+					for _, declaration := range strings.Split(attr.Value, ";") {
+						if strings.HasPrefix(declaration, "fill:") {
+							for _, idAttr := range el.Attr {
+								if idAttr.Name.Local == "id" {
+									result.fills[idAttr.Value] = strings.TrimPrefix(declaration, "fill:")
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRenderStart(t *testing.T) {
+	for _, variant := range variants.OrderedVariants {
+		if _, err := variant.SVGMap(); err != nil {
+			t.Logf("Skipping %v, its map is missing: %v", variant.Name, err)
+			continue
+		}
+		s, err := variant.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Render(variant, s, nil, nil)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		result := parse(t, b)
+		if result.classes["unit"] != len(s.Units()) {
+			t.Errorf("%v: Wanted %v units, got %v", variant.Name, len(s.Units()), result.classes["unit"])
+		}
+		colors := Colors(variant)
+		for prov, nation := range s.SupplyCenters() {
+			if color, found := colors[nation]; found && result.fills[string(prov)] != color {
+				t.Errorf("%v: Wanted %v filled with %v, got %q", variant.Name, prov, color, result.fills[string(prov)])
+			}
+		}
+	}
+}
+
+func TestRenderOrders(t *testing.T) {
+	s, err := classical.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetOrders(map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+		"mar": orders.SupportMove("mar", "par", "bur"),
+		"mun": orders.Move("mun", "bur"),
+		"ber": orders.SupportHold("ber", "kie"),
+		"kie": orders.Move("kie", "hol"),
+		"lon": orders.Move("lon", "nth"),
+		"edi": orders.Convoy("edi", "lvp", "nwy"),
+	})
+	before := s.Clone()
+	if err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Render(classical.ClassicalVariant, before, before.Orders(), s.Resolutions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := parse(t, b)
+	if result.classes["move"] != 4 || result.classes["support"] != 3 || result.classes["convoy"] != 1 {
+		t.Errorf("Wanted 4 moves, 2 supports with a ring for the hold and a convoy, got %v", result.classes)
+	}
+	// The move from mun bounces, the supported unit in kie moves, and the convoy has no army to convoy.
+	if result.classes["failed"] != 3 {
+		t.Errorf("Wanted 3 failed orders, got %v", result.classes["failed"])
+	}
+
+	s, err = classical.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RemoveUnit("par")
+	s.RemoveUnit("bre")
+	s.SetSC("bel", godip.France)
+	s.SetSC("lon", godip.France)
+	s.SetDislodged("mun", godip.Unit{Type: godip.Army, Nation: godip.Germany})
+	b, err = Render(classical.ClassicalVariant, s, map[godip.Province]godip.Adjudicator{
+		"par": orders.Build("par", godip.Army, time.Now()),
+		"bre": orders.Build("bre", godip.Fleet, time.Now()),
+		"kie": orders.Disband("kie", time.Now()),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = parse(t, b)
+	if result.classes["build"] != 4 || result.classes["disband"] != 2 || result.classes["dislodged"] != 1 {
+		t.Errorf("Wanted 2 builds, a disband and a dislodged unit, got %v", result.classes)
+	}
+	if fill := result.fills["lon"]; fill != Colors(classical.ClassicalVariant)[godip.France] {
+		t.Errorf("Wanted lon filled with the French color, got %q", fill)
+	}
+	if result.classes["failed"] != 0 {
+		t.Errorf("Wanted no failed orders without resolutions, got %v", result.classes["failed"])
+	}
+}
+
+const transformedSVG = `<svg xmlns="http://www.w3.org/2000/svg">
+  <g id="provinces" transform="translate(10,10)">
+    <path id="aaa" d="M 0,0 H 100 V 100 H 0 Z" />
+  </g>
+  <g transform="translate(100,0)">
+    <path id="aaaCenter" d="m -50,50 c 1,1 2,2 3,3 z" />
+  </g>
+  <g id="units" transform="translate(20,30)" />
+  <g id="orders" />
+</svg>`
+
+func TestRenderTransforms(t *testing.T) {
+	variant := common.Variant{
+		Name:    "Transformed",
+		Nations: []godip.Nation{godip.France},
+		Graph: func() godip.Graph {
+			return graph.New().Prov("aaa").Flag(godip.Land).Done()
+		},
+		SVGMap: func() ([]byte, error) {
+			return []byte(transformedSVG), nil
+		},
+		SVGVersion: transformedSVG,
+		SVGUnits: map[godip.UnitType]func() ([]byte, error){
+			godip.Army: func() ([]byte, error) {
+				return []byte(`<svg width="10" height="10"><path id="body" d="M 0,0 H 10 V 10 Z" /></svg>`), nil
+			},
+		},
+	}
+	s := state.New(variant.Graph(), nil, nil, nil, nil)
+	s.SetUnit("aaa", godip.Unit{Type: godip.Army, Nation: godip.France})
+	b, err := Render(variant, s, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The center is at 50,50 in the map, which is 30,20 in the units layer, and the unit is 10 wide and high.
+	if !strings.Contains(string(b), `x="25.00" y="15.00" class="unit"`) {
+		t.Errorf("Wanted the unit centered at 30,20 in the units layer, got %s", b)
+	}
+}
@@ -0,0 +1,168 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// element is a start tag of an svg document, with its position in the document.
+type element struct {
+	// start and end are the offsets of the first and after the last byte of the tag.
+	start int64
+	end   int64
+	// name is the name of the element, with its namespace prefix if it has one.
+	name  string
+	attrs []xml.Attr
+	// depth is the number of elements the element is inside, 0 for the root element.
+	depth int
+}
+
+func (self element) attr(name string) string {
+	for _, attr := range self.attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// tag returns the start tag of the element in b.
+func (self element) tag(b []byte) []byte {
+	return b[self.start:self.end]
+}
+
+// selfClosing returns whether the element has no content, e.g. <g id="units" />.
+func (self element) selfClosing(b []byte) bool {
+	return bytes.HasSuffix(self.tag(b), []byte("/>"))
+}
+
+/*
+scan calls f with every start tag of the svg document in b, and returns the offset after the end tag of the root element.
+
+The document isn't decoded into a tree and encoded again, since encoding/xml doesn't keep the namespace prefixes
+(inkscape:label etc.) of the original, but edited by replacing the tags found by scan.
+*/
+func scan(b []byte, f func(el element)) (int64, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	depth := 0
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return 0, fmt.Errorf("Found no root element")
+		} else if err != nil {
+			return 0, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			name := token.Name.Local
+			if token.Name.Space != "" {
+				name = token.Name.Space + ":" + name
+			}
+			f(element{
+				start: start,
+				end:   decoder.InputOffset(),
+				name:  name,
+				attrs: token.Attr,
+				depth: depth,
+			})
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return decoder.InputOffset(), nil
+			}
+		}
+	}
+}
+
+// edit replaces the bytes between start and end of a document with text.
+type edit struct {
+	start int64
+	end   int64
+	text  []byte
+}
+
+// apply returns b with the edits, which must not overlap, applied.
+func apply(b []byte, edits []edit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+	result := &bytes.Buffer{}
+	pos := int64(0)
+	for _, edit := range edits {
+		result.Write(b[pos:edit.start])
+		result.Write(edit.text)
+		pos = edit.end
+	}
+	result.Write(b[pos:])
+	return result.Bytes()
+}
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;")
+
+// setAttr returns tag with the attribute name set to value, replacing any existing value.
+func setAttr(tag []byte, name string, value string) []byte {
+	escaped := []byte(fmt.Sprintf("%s=\"%s\"", name, attrEscaper.Replace(value)))
+	pattern := regexp.MustCompile(`(\s)` + regexp.QuoteMeta(name) + `\s*=\s*("[^"]*"|'[^']*')`)
+	if loc := pattern.FindSubmatchIndex(tag); loc != nil {
+		return append(append(append([]byte{}, tag[:loc[3]]...), escaped...), tag[loc[1]:]...)
+	}
+	insertAt := len(tag) - 1
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		insertAt = len(tag) - 2
+	}
+	return append(append(append(append([]byte{}, tag[:insertAt]...), ' '), escaped...), tag[insertAt:]...)
+}
+
+// setStyle returns tag with the properties, alternating names and values, set in its style attribute, which is style
+// before the change.
+func setStyle(tag []byte, style string, properties ...string) []byte {
+	names := []string{}
+	values := map[string]string{}
+	for _, declaration := range strings.Split(style, ";") {
+		parts := strings.SplitN(declaration, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		if _, found := values[name]; !found {
+			names = append(names, name)
+		}
+		values[name] = strings.TrimSpace(parts[1])
+	}
+	for i := 0; i+1 < len(properties); i += 2 {
+		if _, found := values[properties[i]]; !found {
+			names = append(names, properties[i])
+		}
+		values[properties[i]] = properties[i+1]
+	}
+	declarations := make([]string, len(names))
+	for i, name := range names {
+		declarations[i] = name + ":" + values[name]
+	}
+	return setAttr(tag, "style", strings.Join(declarations, ";"))
+}
+
+// content returns the edit adding text as the first content of el.
+func content(b []byte, el element, text []byte) edit {
+	if el.selfClosing(b) {
+		tag := el.tag(b)
+		opened := append(append([]byte{}, bytes.TrimRight(tag[:len(tag)-2], " \t\r\n")...), '>')
+		return edit{
+			start: el.start,
+			end:   el.end,
+			text:  append(append(opened, text...), []byte("</"+el.name+">")...),
+		}
+	}
+	return edit{
+		start: el.end,
+		end:   el.end,
+		text:  text,
+	}
+}
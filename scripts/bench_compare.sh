@@ -0,0 +1,96 @@
+#!/bin/sh
+#
+# Compares the adjudication benchmarks in ./variants between two revisions.
+#
+# Usage: scripts/bench_compare.sh [OLD [NEW]]
+#
+# OLD defaults to HEAD, and NEW to the working tree. The environment variables
+# BENCH (default "."), COUNT (default 5) and BENCHTIME (default 1s) are passed
+# to go test, and benchmarks getting more than THRESHOLD (default 10) percent
+# slower make the script exit with status 1.
+#
+# If benchstat (golang.org/x/perf/cmd/benchstat) is installed, its comparison
+# is printed as well.
+
+set -e
+
+OLD=${1:-HEAD}
+NEW=${2:-}
+BENCH=${BENCH:-.}
+COUNT=${COUNT:-5}
+BENCHTIME=${BENCHTIME:-1s}
+THRESHOLD=${THRESHOLD:-10}
+
+ROOT=$(git rev-parse --show-toplevel)
+WORK=$(mktemp -d)
+trap 'git -C "$ROOT" worktree remove --force "$WORK/old" >/dev/null 2>&1 || true; git -C "$ROOT" worktree remove --force "$WORK/new" >/dev/null 2>&1 || true; rm -rf "$WORK"' EXIT
+
+# checkout creates a worktree of a revision, with the Go files git ignores (e.g. locally generated assets) copied from the
+# working tree, since the revision can't be built without them.
+checkout() {
+	git -C "$ROOT" worktree add --quiet --detach "$2" "$1" >/dev/null
+	(cd "$ROOT" && git ls-files --others --ignored --exclude-standard -- '*.go') | while read -r file; do
+		mkdir -p "$(dirname "$2/$file")"
+		cp "$ROOT/$file" "$2/$file"
+	done
+}
+
+bench() {
+	echo "Benchmarking $1 ..." >&2
+	(cd "$2/variants" && go test -run '^$' -bench "$BENCH" -count "$COUNT" -benchtime "$BENCHTIME" .) > "$3"
+}
+
+checkout "$OLD" "$WORK/old"
+bench "$OLD" "$WORK/old" "$WORK/old.txt"
+if [ -z "$NEW" ]; then
+	bench "the working tree" "$ROOT" "$WORK/new.txt"
+else
+	checkout "$NEW" "$WORK/new"
+	bench "$NEW" "$WORK/new" "$WORK/new.txt"
+fi
+
+if command -v benchstat >/dev/null 2>&1; then
+	benchstat "$WORK/old.txt" "$WORK/new.txt"
+	echo
+fi
+
+status=0
+awk -v threshold="$THRESHOLD" '
+	FNR == 1 { file++ }
+	/^Benchmark/ && $4 == "ns/op" {
+		name = $1
+		sub(/-[0-9]+$/, "", name)
+		sum[file, name] += $3
+		n[file, name]++
+		names[name] = 1
+	}
+	END {
+		slower = 0
+		for (name in names) {
+			if (n[1, name] == 0 || n[2, name] == 0) {
+				printf "%-60s %15s %15s %8s\n", name, n[1, name] ? sprintf("%.0f", sum[1, name] / n[1, name]) : "-", n[2, name] ? sprintf("%.0f", sum[2, name] / n[2, name]) : "-", ""
+				continue
+			}
+			old = sum[1, name] / n[1, name]
+			new = sum[2, name] / n[2, name]
+			delta = (new - old) * 100 / old
+			mark = ""
+			if (delta > threshold) {
+				mark = " SLOWER"
+				slower++
+			}
+			printf "%-60s %15.0f %15.0f %+7.1f%%%s\n", name, old, new, delta, mark
+		}
+		if (slower > 0) {
+			exit 1
+		}
+	}
+' "$WORK/old.txt" "$WORK/new.txt" > "$WORK/comparison.txt" || status=$?
+
+printf "%-60s %15s %15s %8s\n" benchmark "old ns/op" "new ns/op" delta
+sort "$WORK/comparison.txt"
+if [ "$status" -ne 0 ]; then
+	echo "Benchmarks got more than $THRESHOLD% slower" >&2
+fi
+exit "$status"
+
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants"
+	"github.com/zond/godip/variants/common"
+)
+
+type contextKey int
+
+const variantKey contextKey = iota
+
+// withVariant returns a handler that sets the CORS headers, looks up the variant named by the "variant" path variable
+// and serves the request with handler, with the variant in the request context, or responds 404 if there is no such
+// variant.
+func withVariant(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		corsHeaders(w)
+		variantName := mux.Vars(r)["variant"]
+		variant, found := variants.Variants[variantName]
+		if !found {
+			http.Error(w, fmt.Sprintf("Variant %q not found", variantName), 404)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), variantKey, variant)))
+	}
+}
+
+// requestVariant returns the variant put in the request context by withVariant.
+func requestVariant(r *http.Request) common.Variant {
+	return r.Context().Value(variantKey).(common.Variant)
+}
+
+type Phase struct {
+	Season        godip.Season
+	Year          int
+	Type          godip.PhaseType
+	Units         map[godip.Province]godip.Unit
+	Orders        map[godip.Nation]map[godip.Province][]string
+	SupplyCenters map[godip.Province]godip.Nation
+	Dislodgeds    map[godip.Province]godip.Unit
+	Dislodgers    map[godip.Province]godip.Province
+	Bounces       map[godip.Province]map[godip.Province]bool
+	Resolutions   map[godip.Province]string
+	Explanations  map[godip.Province]*godip.Explanation `json:",omitempty"`
+}
+
+func NewPhase(state *state.State) *Phase {
+	currentPhase := state.Phase()
+	p := &Phase{
+		Orders:      map[godip.Nation]map[godip.Province][]string{},
+		Resolutions: map[godip.Province]string{},
+		Season:      currentPhase.Season(),
+		Year:        currentPhase.Year(),
+		Type:        currentPhase.Type(),
+	}
+	var resolutions map[godip.Province]error
+	p.Units, p.SupplyCenters, p.Dislodgeds, p.Dislodgers, p.Bounces, resolutions = state.Dump()
+	if explanations := state.Explanations(); len(explanations) > 0 {
+		p.Explanations = explanations
+	}
+	for prov, err := range resolutions {
+		if err == nil {
+			p.Resolutions[prov] = "OK"
+		} else {
+			p.Resolutions[prov] = err.Error()
+		}
+	}
+	return p
+}
+
+// State returns a state for the phase, using the parser, phase and blank state
+// (including flags, backup rule and neutral orders) of the provided variant.
+func (self *Phase) State(variant common.Variant) (*state.State, error) {
+	parsedOrders, err := variant.Parser.ParseAll(self.Orders)
+	if err != nil {
+		return nil, err
+	}
+	return self.load(variant, parsedOrders), nil
+}
+
+func (self *Phase) load(variant common.Variant, parsedOrders map[godip.Province]godip.Adjudicator) *state.State {
+	return variant.Blank(variant.Phase(
+		self.Year,
+		self.Season,
+		self.Type,
+	)).Load(
+		self.Units,
+		self.SupplyCenters,
+		self.Dislodgeds,
+		self.Dislodgers,
+		self.Bounces,
+		parsedOrders,
+	)
+}
+
+// Validation describes the problems with the orders of a phase, without adjudicating them.
+type Validation struct {
+	// ParseErrors contains the orders that couldn't be parsed, per nation and province.
+	ParseErrors map[godip.Nation]map[godip.Province]string
+	// ValidationErrors contains the parsed orders that aren't valid, per nation and province.
+	ValidationErrors map[godip.Nation]map[godip.Province]string
+	// Inconsistencies contains the result of corroborating the orders of each nation.
+	Inconsistencies map[godip.Nation][]godip.Inconsistency
+}
+
+// Validate parses, validates and corroborates the orders of the phase using the provided variant.
+// Orders that can't be parsed are left out when validating and corroborating the rest.
+func (self *Phase) Validate(variant common.Variant) *Validation {
+	result := &Validation{
+		ParseErrors:      map[godip.Nation]map[godip.Province]string{},
+		ValidationErrors: map[godip.Nation]map[godip.Province]string{},
+		Inconsistencies:  map[godip.Nation][]godip.Inconsistency{},
+	}
+	parsedOrders := map[godip.Province]godip.Adjudicator{}
+	for nation, nationOrders := range self.Orders {
+		for prov, bits := range nationOrders {
+			if parsed, err := variant.Parser.Parse(append([]string{string(prov)}, bits...)); err == nil {
+				parsedOrders[prov] = parsed
+			} else {
+				if result.ParseErrors[nation] == nil {
+					result.ParseErrors[nation] = map[godip.Province]string{}
+				}
+				result.ParseErrors[nation][prov] = err.Error()
+			}
+		}
+	}
+	s := self.load(variant, parsedOrders)
+	for nation, nationOrders := range self.Orders {
+		for prov := range nationOrders {
+			order, found := parsedOrders[prov]
+			if !found {
+				continue
+			}
+			if _, err := order.Validate(s); err != nil {
+				if result.ValidationErrors[nation] == nil {
+					result.ValidationErrors[nation] = map[godip.Province]string{}
+				}
+				result.ValidationErrors[nation][prov] = err.Error()
+			}
+		}
+	}
+	for _, nation := range variant.Nations {
+		if inconsistencies := s.Corroborate(nation); len(inconsistencies) > 0 {
+			result.Inconsistencies[nation] = inconsistencies
+		}
+	}
+	return result
+}
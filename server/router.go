@@ -0,0 +1,252 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zond/godip"
+	"github.com/zond/godip/render"
+	"github.com/zond/godip/variants"
+)
+
+func corsHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "*")
+}
+
+// decodeErrorStatus returns 413 if the request body was cut short by http.MaxBytesReader, and 400 otherwise.
+func decodeErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return 413
+	}
+	return 400
+}
+
+func preflight(w http.ResponseWriter, r *http.Request) {
+	corsHeaders(w)
+}
+
+func resolve(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	p := &Phase{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+	state, err := p.State(variant)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err = state.Next(); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	// Load the new godip phase from the state
+	nextPhase := NewPhase(state)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(nextPhase); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+func validate(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	p := &Phase{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+	validation := p.Validate(variant)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(validation); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+func resolveWithOptions(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	p := &Phase{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+	state, err := p.State(variant)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err = state.Next(); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	nextPhase := NewPhase(state)
+
+	options := state.AllOptions()
+
+	response := struct {
+		Phase   *Phase                         `json:"phase"`
+		Options map[godip.Nation]godip.Options `json:"options"`
+	}{
+		Phase:   nextPhase,
+		Options: options,
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+func start(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	state, err := variant.Start()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	phase := NewPhase(state)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(phase); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+func startWithOptions(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	state, err := variant.Start()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	phase := NewPhase(state)
+
+	options := state.AllOptions()
+	response := struct {
+		Phase   *Phase                         `json:"phase"`
+		Options map[godip.Nation]godip.Options `json:"options"`
+	}{
+		Phase:   phase,
+		Options: options,
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+func writeSVG(w http.ResponseWriter, b []byte) {
+	w.Header().Set("Content-Type", "image/svg+xml; charset=UTF-8")
+	if _, err := w.Write(b); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+// renderPhase draws the phase and its orders on the map of the variant, with the orders that fail when adjudicated
+// crossed out.
+func renderPhase(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	p := &Phase{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+	state, err := p.State(variant)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	resolved := state.Clone()
+	if err = resolved.Next(); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	b, err := render.Render(variant, state, state.Orders(), resolved.Resolutions())
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeSVG(w, b)
+}
+
+func renderStart(w http.ResponseWriter, r *http.Request) {
+	variant := requestVariant(r)
+	state, err := variant.Start()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	b, err := render.Render(variant, state, nil, nil)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeSVG(w, b)
+}
+
+// svgAsset serves the map, a unit or a flag svg of a variant, found by the "unit" or "nation" path variable if given.
+func svgAsset(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	variant := requestVariant(r)
+	asset, found, name := variant.SVGMap, true, "map"
+	if unitType, isUnit := vars["unit"]; isUnit {
+		asset, found = variant.SVGUnits[godip.UnitType(unitType)]
+		name = fmt.Sprintf("unit type %q", unitType)
+	} else if nation, isFlag := vars["nation"]; isFlag {
+		asset, found = variant.SVGFlags[godip.Nation(nation)]
+		name = fmt.Sprintf("nation %q", nation)
+	}
+	if !found || asset == nil {
+		http.Error(w, fmt.Sprintf("%v has no svg for the %v", variant.Name, name), 404)
+		return
+	}
+	b, err := asset()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeSVG(w, b)
+}
+
+func listVariants(w http.ResponseWriter, r *http.Request) {
+	corsHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(variants.Variants); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
+// NewRouter returns a router serving the adjudication API for all variants in variants.Variants.
+func NewRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.Methods("OPTIONS").HandlerFunc(preflight)
+	variants := r.Path("/{variant}").Subrouter()
+	variants.Methods("POST").HandlerFunc(withVariant(resolve))
+	variants.Methods("GET").HandlerFunc(withVariant(start))
+	r.Path("/start-with-options/{variant}").Methods("GET").HandlerFunc(withVariant(startWithOptions))
+	r.Path("/resolve-with-options/{variant}").Methods("POST").HandlerFunc(withVariant(resolveWithOptions))
+	r.Path("/validate/{variant}").Methods("POST").HandlerFunc(withVariant(validate))
+	r.Path("/render/{variant}").Methods("POST").HandlerFunc(withVariant(renderPhase))
+	r.Path("/{variant}/map.svg").Methods("GET").HandlerFunc(withVariant(renderStart))
+	r.Path("/{variant}/svg/map.svg").Methods("GET").HandlerFunc(withVariant(svgAsset))
+	r.Path("/{variant}/svg/units/{unit}.svg").Methods("GET").HandlerFunc(withVariant(svgAsset))
+	r.Path("/{variant}/svg/flags/{nation}.svg").Methods("GET").HandlerFunc(withVariant(svgAsset))
+	r.Path("/").HandlerFunc(listVariants)
+	return r
+}
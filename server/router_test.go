@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+	"github.com/zond/godip/variants"
+	"github.com/zond/godip/variants/classical"
+	"github.com/zond/godip/variants/common"
+)
+
+// preferredOrderTypes decides which order type the test picks for each province, to get as many moves, builds and disbands as possible.
+var preferredOrderTypes = []godip.OrderType{
+	godip.Move,
+	godip.Build,
+	godip.Disband,
+	godip.Support,
+	godip.Hold,
+}
+
+func sortedOptionKeys(opts godip.Options) []godip.OptionValue {
+	keys := make([]godip.OptionValue, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+func firstOptionPath(opts godip.Options) (result []string) {
+	for len(opts) > 0 {
+		key := sortedOptionKeys(opts)[0]
+		result = append(result, fmt.Sprint(key))
+		opts = opts[key]
+	}
+	return
+}
+
+// deterministicOrders picks one order per province for every nation, in the format the HTTP API expects.
+func deterministicOrders(s *state.State, variant common.Variant) map[godip.Nation]map[godip.Province][]string {
+	result := map[godip.Nation]map[godip.Province][]string{}
+	for _, nation := range variant.Nations {
+		nationOrders := map[godip.Province][]string{}
+		builds := 0
+		for _, owner := range s.SupplyCenters() {
+			if owner == nation {
+				builds++
+			}
+		}
+		for _, unit := range s.Units() {
+			if unit.Nation == nation {
+				builds--
+			}
+		}
+		usedProvinces := map[godip.Province]bool{}
+		options := s.Phase().Options(s, nation)
+		for _, provKey := range sortedOptionKeys(options) {
+			prov := provKey.(godip.Province)
+			if usedProvinces[prov.Super()] {
+				continue
+			}
+			for _, typ := range preferredOrderTypes {
+				typOpts, found := options[prov][typ]
+				if !found {
+					continue
+				}
+				if typ == godip.Build {
+					if builds < 1 {
+						continue
+					}
+					builds--
+				} else if typ == godip.Disband && s.Phase().Type() == godip.Adjustment {
+					if builds > -1 {
+						continue
+					}
+					builds++
+				}
+				path := firstOptionPath(typOpts)
+				if typ == godip.Build {
+					// The build options are UnitType -> SrcProvince, but the order is parsed as Build UnitType.
+					nationOrders[prov] = []string{string(typ), path[0]}
+				} else {
+					// The other options are SrcProvince -> targets, but the order is parsed as OrderType targets.
+					nationOrders[prov] = append([]string{string(typ)}, path[1:]...)
+				}
+				usedProvinces[prov.Super()] = true
+				break
+			}
+		}
+		result[nation] = nationOrders
+	}
+	return result
+}
+
+func request(t *testing.T, handler http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, buf)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func roundTrip(t *testing.T, handler http.Handler, method, path string, body interface{}, result interface{}) {
+	rec := request(t, handler, method, path, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%v %v returned %v: %v", method, path, rec.Code, rec.Body.String())
+	}
+	if err := json.NewDecoder(rec.Body).Decode(result); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jsonCopy(t *testing.T, phase *Phase) *Phase {
+	b, err := json.Marshal(phase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &Phase{}
+	if err := json.Unmarshal(b, result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// comparablePhase returns a JSON copy of the phase without the explanations and the province names in the resolutions, since which of several
+// competing units an ErrBounce names, and the order of the supports in the explanations, depends on map iteration order.
+func comparablePhase(t *testing.T, phase *Phase) *Phase {
+	result := jsonCopy(t, phase)
+	result.Explanations = nil
+	for prov, resolution := range result.Resolutions {
+		result.Resolutions[prov] = strings.Split(resolution, ":")[0]
+	}
+	return result
+}
+
+func TestFirstYearOverHTTP(t *testing.T) {
+	router := NewRouter()
+	for _, variant := range variants.OrderedVariants {
+		variant := variant
+		t.Run(variant.Name, func(t *testing.T) {
+			local, err := variant.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := &Phase{}
+			roundTrip(t, router, "GET", "/"+url.PathEscape(variant.Name), nil, remote)
+			if got, want := comparablePhase(t, remote), comparablePhase(t, NewPhase(local)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Got start %+v, wanted %+v", got, want)
+			}
+			startYear := local.Phase().Year()
+			for local.Phase().Year() == startYear {
+				phaseName := fmt.Sprintf("%v %v %v", local.Phase().Year(), local.Phase().Season(), local.Phase().Type())
+				remote.Orders = deterministicOrders(local, variant)
+				parsed, err := variant.Parser.ParseAll(remote.Orders)
+				if err != nil {
+					t.Fatalf("%v: %v", phaseName, err)
+				}
+				local.SetOrders(parsed)
+				if err := local.Next(); err != nil {
+					t.Fatalf("%v: %v", phaseName, err)
+				}
+				next := &Phase{}
+				roundTrip(t, router, "POST", "/"+url.PathEscape(variant.Name), remote, next)
+				if got, want := comparablePhase(t, next), comparablePhase(t, NewPhase(local)); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v: got %+v, wanted %+v", phaseName, got, want)
+				}
+				remote = next
+			}
+		})
+	}
+}
+
+func TestUnknownVariant(t *testing.T) {
+	router := NewRouter()
+	for _, route := range []struct{ method, path string }{
+		{"GET", "/Mordor"},
+		{"POST", "/Mordor"},
+		{"GET", "/start-with-options/Mordor"},
+		{"POST", "/resolve-with-options/Mordor"},
+		{"POST", "/validate/Mordor"},
+		{"POST", "/render/Mordor"},
+		{"GET", "/Mordor/svg/map.svg"},
+	} {
+		rec := request(t, router, route.method, route.path, &Phase{})
+		if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `Variant "Mordor" not found`) {
+			t.Errorf("%v %v returned %v %q, wanted %v", route.method, route.path, rec.Code, rec.Body.String(), http.StatusNotFound)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("%v %v returned no CORS headers", route.method, route.path)
+		}
+	}
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	handler := http.MaxBytesHandler(NewRouter(), 16)
+	req := httptest.NewRequest("POST", "/Classical", strings.NewReader(`{"Season":"Spring","Year":1901,"Type":"Movement"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Got %v, wanted %v", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	router := NewRouter()
+	phase := &Phase{}
+	roundTrip(t, router, "GET", "/Classical", nil, phase)
+	phase.Orders = map[godip.Nation]map[godip.Province][]string{
+		godip.England: {
+			"lon": {"Move", "eng"},
+			"lvp": {"Move", "mos"},
+			"edi": {"Fly", "nth"},
+		},
+		godip.France: {
+			"par": {"Hold"},
+			"mar": {"Hold"},
+			"bre": {"Support", "par", "bur"},
+		},
+	}
+	result := &struct {
+		ParseErrors      map[godip.Nation]map[godip.Province]string
+		ValidationErrors map[godip.Nation]map[godip.Province]string
+		Inconsistencies  map[godip.Nation][]struct {
+			Province        godip.Province
+			Inconsistencies []string
+		}
+	}{}
+	roundTrip(t, router, "POST", "/validate/Classical", phase, result)
+	if len(result.ParseErrors) != 1 || result.ParseErrors[godip.England]["edi"] == "" {
+		t.Errorf("Wanted only a parse error for edi, got %+v", result.ParseErrors)
+	}
+	wantValidationErrors := map[godip.Nation]map[godip.Province]string{
+		godip.England: {"lvp": godip.ErrMissingConvoyPath.Error()},
+		godip.France:  {"bre": godip.ErrIllegalSupportDestination.Error()},
+	}
+	if !reflect.DeepEqual(result.ValidationErrors, wantValidationErrors) {
+		t.Errorf("Got validation errors %+v, wanted %+v", result.ValidationErrors, wantValidationErrors)
+	}
+	// France has orders for all units, so only the other nations have inconsistencies.
+	if _, found := result.Inconsistencies[godip.France]; found || len(result.Inconsistencies) != len(classical.Nations)-1 {
+		t.Errorf("Wanted inconsistencies for all nations but France, got %+v", result.Inconsistencies)
+	}
+	wantEnglish := map[godip.Province][]string{
+		"edi": {godip.InconsistencyMissingOrder.Error()},
+	}
+	gotEnglish := map[godip.Province][]string{}
+	for _, inconsistency := range result.Inconsistencies[godip.England] {
+		gotEnglish[inconsistency.Province] = inconsistency.Inconsistencies
+	}
+	if !reflect.DeepEqual(gotEnglish, wantEnglish) {
+		t.Errorf("Got English inconsistencies %+v, wanted %+v", gotEnglish, wantEnglish)
+	}
+}
+
+func TestRender(t *testing.T) {
+	router := NewRouter()
+	svg := func(method, path string, body interface{}) string {
+		rec := request(t, router, method, path, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%v %v returned %v: %v", method, path, rec.Code, rec.Body.String())
+		}
+		if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "image/svg+xml") {
+			t.Errorf("%v %v returned content type %q", method, path, contentType)
+		}
+		return rec.Body.String()
+	}
+	start := svg("GET", "/Classical/map.svg", nil)
+	if units := strings.Count(start, `class="unit"`); units != 22 {
+		t.Errorf("Wanted 22 units on the start map, got %v", units)
+	}
+
+	phase := &Phase{}
+	roundTrip(t, router, "GET", "/Classical", nil, phase)
+	phase.Orders = map[godip.Nation]map[godip.Province][]string{
+		godip.France: {
+			"par": {"Move", "bur"},
+		},
+		godip.Germany: {
+			"mun": {"Move", "bur"},
+		},
+		godip.England: {
+			"lon": {"Move", "eng"},
+		},
+	}
+	rendered := svg("POST", "/render/Classical", phase)
+	if moves := strings.Count(rendered, `class="order move"`); moves != 3 {
+		t.Errorf("Wanted 3 moves, got %v", moves)
+	}
+	if failed := strings.Count(rendered, `class="failed"`); failed != 2 {
+		t.Errorf("Wanted the 2 bouncing moves crossed out, got %v", failed)
+	}
+
+	for path, asset := range map[string]func() ([]byte, error){
+		"/Classical/svg/map.svg":          classical.ClassicalVariant.SVGMap,
+		"/Classical/svg/units/Fleet.svg":  classical.SVGUnits[godip.Fleet],
+		"/Classical/svg/flags/France.svg": classical.SVGFlags[godip.France],
+	} {
+		want, err := asset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := svg("GET", path, nil); got != string(want) {
+			t.Errorf("GET %v didn't return the svg", path)
+		}
+	}
+	for _, path := range []string{"/Classical/svg/units/Tank.svg", "/Classical/svg/flags/Mordor.svg", "/Middle%20Earth/map.svg"} {
+		if rec := request(t, router, "GET", path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %v returned %v, wanted %v", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.21
+
+package godip
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger returns a Logger that logs each line as a message with the provided level to logger.
+func SlogLogger(logger *slog.Logger, level slog.Level) Logger {
+	return NewLogger(func(line string) {
+		logger.Log(context.Background(), level, line)
+	})
+}
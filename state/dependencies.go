@@ -0,0 +1,124 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zond/godip"
+)
+
+// DependencyNode is one adjudicated order in a dependency graph.
+type DependencyNode struct {
+	Province godip.Province
+	// Order is the order that was adjudicated, as a string.
+	Order string
+	// Resolution is "OK" if the order succeeded, and the error otherwise.
+	Resolution string
+	// DependsOn are the provinces whose resolutions were asked for while adjudicating the order.
+	DependsOn []godip.Province `json:",omitempty"`
+	// Guessed is true if a guess was made for the resolution of the order, because it depended on itself.
+	Guessed bool `json:",omitempty"`
+	// BackupRule is true if the resolution of the order was decided by the backup rule.
+	BackupRule bool `json:",omitempty"`
+}
+
+// Dependencies is the graph of how the resolutions of the orders depended on each other during a call to Next.
+type Dependencies struct {
+	Nodes map[godip.Province]*DependencyNode
+	// Cycles are the provinces the backup rule was called with, in the order it was called.
+	Cycles [][]godip.Province `json:",omitempty"`
+}
+
+func newDependencies() *Dependencies {
+	return &Dependencies{
+		Nodes: map[godip.Province]*DependencyNode{},
+	}
+}
+
+func (self *Dependencies) node(prov godip.Province) *DependencyNode {
+	node, found := self.Nodes[prov]
+	if !found {
+		node = &DependencyNode{Province: prov}
+		self.Nodes[prov] = node
+	}
+	return node
+}
+
+func (self *Dependencies) addDependency(from, to godip.Province) {
+	node := self.node(from)
+	for _, dep := range node.DependsOn {
+		if dep == to {
+			return
+		}
+	}
+	node.DependsOn = append(node.DependsOn, to)
+}
+
+func (self *Dependencies) addCycle(deps []godip.Province) {
+	self.Cycles = append(self.Cycles, append([]godip.Province(nil), deps...))
+	for _, dep := range deps {
+		self.node(dep).BackupRule = true
+	}
+}
+
+// Copy returns a deep copy of the dependencies.
+func (self *Dependencies) Copy() *Dependencies {
+	if self == nil {
+		return nil
+	}
+	result := newDependencies()
+	for prov, node := range self.Nodes {
+		nodeCopy := *node
+		nodeCopy.DependsOn = append([]godip.Province(nil), node.DependsOn...)
+		result.Nodes[prov] = &nodeCopy
+	}
+	for _, cycle := range self.Cycles {
+		result.Cycles = append(result.Cycles, append([]godip.Province(nil), cycle...))
+	}
+	return result
+}
+
+func (self *Dependencies) sortedProvinces() []godip.Province {
+	result := make([]godip.Province, 0, len(self.Nodes))
+	for prov := range self.Nodes {
+		result = append(result, prov)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+/*
+WriteDOT writes the dependencies as a Graphviz digraph, with an edge from each order to the orders it depended on.
+
+Orders that were guessed are dashed, and orders resolved by the backup rule are red.
+*/
+func (self *Dependencies) WriteDOT(w io.Writer) error {
+	lines := []string{"digraph dependencies {"}
+	for _, prov := range self.sortedProvinces() {
+		node := self.Nodes[prov]
+		attributes := []string{fmt.Sprintf("label=%q", fmt.Sprintf("%v\n%v", node.Order, node.Resolution))}
+		if node.Guessed {
+			attributes = append(attributes, "style=dashed")
+		}
+		if node.BackupRule {
+			attributes = append(attributes, "color=red")
+		}
+		lines = append(lines, fmt.Sprintf("\t%q [%v];", prov, strings.Join(attributes, ", ")))
+	}
+	for _, prov := range self.sortedProvinces() {
+		deps := append([]godip.Province(nil), self.Nodes[prov].DependsOn...)
+		sort.Slice(deps, func(i, j int) bool {
+			return deps[i] < deps[j]
+		})
+		for _, dep := range deps {
+			lines = append(lines, fmt.Sprintf("\t%q -> %q;", prov, dep))
+		}
+	}
+	lines = append(lines, "}")
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
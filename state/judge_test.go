@@ -42,6 +42,9 @@ func (self testOrder) Validate(godip.Validator) (godip.Nation, error) {
 }
 func (self testOrder) Execute(godip.State) {
 }
+func (self testOrder) Clone() godip.Adjudicator {
+	return self
+}
 
 /*
      C
@@ -81,3 +84,63 @@ func TestStateLocations(t *testing.T) {
 	assertOrderLocation(t, j, "b/ec", testOrder(2), true)
 	assertOrderLocation(t, j, "b/nc", testOrder(2), true)
 }
+
+func TestClone(t *testing.T) {
+	j := New(testGraph(), nil, nil, map[godip.Flag]bool{godip.Land: true}, nil)
+	j.SetUnit("a", godip.Unit{godip.Army, "x"})
+	j.SetDislodged("c", godip.Unit{godip.Fleet, "y"})
+	j.SetSC("a", "x")
+	j.SetDislodger("b", "c")
+	j.AddBounce("b", "d")
+	j.SetOrder("a", testOrder(1))
+	c := j.Clone()
+	if c.Graph() != j.Graph() {
+		t.Errorf("Wanted the clone to share the graph")
+	}
+	c.SetUnit("b/sc", godip.Unit{godip.Fleet, "x"})
+	c.RemoveUnit("a")
+	c.RemoveDislodged("c")
+	c.SetSC("a", "y")
+	c.SetSC("d", "y")
+	c.SetDislodger("a", "b")
+	c.AddBounce("c", "d")
+	c.SetOrder("b", testOrder(2))
+	delete(c.Orders(), "a")
+	c.Flags()[godip.Sea] = true
+	if units := j.Units(); len(units) != 1 || units["a"] != (godip.Unit{godip.Army, "x"}) {
+		t.Errorf("Wanted the original units to be untouched, got %v", units)
+	}
+	if dislodgeds := j.Dislodgeds(); len(dislodgeds) != 1 {
+		t.Errorf("Wanted the original dislodgeds to be untouched, got %v", dislodgeds)
+	}
+	if scs := j.SupplyCenters(); len(scs) != 1 || scs["a"] != "x" {
+		t.Errorf("Wanted the original supply centers to be untouched, got %v", scs)
+	}
+	if _, _, _, dislodgers, bounces, _ := j.Dump(); len(dislodgers) != 1 || len(bounces["d"]) != 1 {
+		t.Errorf("Wanted the original dislodgers and bounces to be untouched, got %v and %v", dislodgers, bounces)
+	}
+	assertOrderLocation(t, j, "a", testOrder(1), true)
+	assertOrderLocation(t, j, "b", nil, false)
+	if flags := j.Flags(); len(flags) != 1 {
+		t.Errorf("Wanted the original flags to be untouched, got %v", flags)
+	}
+	if units := c.Units(); len(units) != 1 || units["b/sc"] != (godip.Unit{godip.Fleet, "x"}) {
+		t.Errorf("Wanted the clone to have its own units, got %v", units)
+	}
+}
+
+func TestLoadCopies(t *testing.T) {
+	units := map[godip.Province]godip.Unit{"a": {godip.Army, "x"}}
+	scs := map[godip.Province]godip.Nation{"a": "x"}
+	j := New(testGraph(), nil, nil, nil, nil).Load(units, scs, map[godip.Province]godip.Unit{}, map[godip.Province]godip.Province{}, map[godip.Province]map[godip.Province]bool{}, map[godip.Province]godip.Adjudicator{})
+	j.RemoveUnit("a")
+	j.SetSC("b", "x")
+	if len(units) != 1 || len(scs) != 1 {
+		t.Errorf("Wanted Load to copy its arguments, got %v and %v", units, scs)
+	}
+	j.SetSupplyCenters(scs)
+	j.SetSC("c", "x")
+	if len(scs) != 1 {
+		t.Errorf("Wanted SetSupplyCenters to copy its argument, got %v", scs)
+	}
+}
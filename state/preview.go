@@ -0,0 +1,84 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/zond/godip"
+)
+
+// Preview is the outcome of adjudicating hypothetical orders on a copy of a state.
+type Preview struct {
+	// Phase is the phase the state would be in after adjudication.
+	Phase godip.Phase
+	// Units are the units after adjudication.
+	Units map[godip.Province]godip.Unit
+	// Dislodgeds are the units dislodged by the adjudication.
+	Dislodgeds map[godip.Province]godip.Unit
+	// SupplyCenters are the supply centers after adjudication.
+	SupplyCenters map[godip.Province]godip.Nation
+	// SupplyCenterChanges contains the new owner of each supply center that changed owner during adjudication.
+	SupplyCenterChanges map[godip.Province]godip.Nation
+	// Resolutions are the resolutions of the hypothetical orders and the default orders of units without one.
+	Resolutions map[godip.Province]error
+	// Explanations describe the resolutions.
+	Explanations map[godip.Province]*godip.Explanation
+	// State is the private copy the orders were adjudicated in, for further exploration.
+	State *State
+}
+
+// Preview adjudicates orders, instead of the orders of this state, on a private copy of this state and returns the
+// outcome. This state is left untouched.
+// If the adjudication leads to a retreat phase without dislodged units, that phase is adjudicated as well, so that
+// supply centers changing owner at the end of a year show up in the outcome.
+// The orders are copied before adjudication, so they are left untouched too, and can be shared between previews.
+func (self *State) Preview(orders map[godip.Province]godip.Adjudicator) (*Preview, error) {
+	preview := self.Clone()
+	preview.SetOrders(cloneOrders(orders))
+	if err := preview.Next(); err != nil {
+		return nil, err
+	}
+	result := &Preview{
+		Resolutions:  preview.Resolutions(),
+		Explanations: preview.Explanations(),
+		State:        preview,
+	}
+	if preview.Phase().Type() == godip.Retreat && len(preview.Dislodgeds()) == 0 {
+		if err := preview.Next(); err != nil {
+			return nil, err
+		}
+	}
+	result.Phase = preview.Phase()
+	result.Units = preview.Units()
+	result.Dislodgeds = preview.Dislodgeds()
+	result.SupplyCenters = preview.SupplyCenters()
+	result.SupplyCenterChanges = map[godip.Province]godip.Nation{}
+	for prov, nation := range preview.SupplyCenters() {
+		if self.supplyCenters[prov] != nation {
+			result.SupplyCenterChanges[prov] = nation
+		}
+	}
+	return result, nil
+}
+
+// PreviewAll runs Preview for each scenario in parallel, and returns the outcomes in the same order as the scenarios.
+// The first error encountered is returned together with the outcomes of the scenarios that succeeded.
+// The logger of this state is shared by all scenarios, so it has to be safe for concurrent use, like godip.GlobalLogger.
+func (self *State) PreviewAll(scenarios []map[godip.Province]godip.Adjudicator) ([]*Preview, error) {
+	results := make([]*Preview, len(scenarios))
+	errs := make([]error, len(scenarios))
+	wg := sync.WaitGroup{}
+	for index := range scenarios {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			results[index], errs[index] = self.Preview(scenarios[index])
+		}(index)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
@@ -8,79 +8,109 @@ import (
 
 type resolver struct {
 	*State
-	deps      []godip.Province
-	guesses   map[godip.Province]error
-	resolving map[godip.Province]bool
+	deps       []godip.Province
+	guesses    map[godip.Province]error
+	resolving  map[godip.Province]bool
+	explaining []*godip.Explanation
+	// adjudicating are the provinces of the orders currently being adjudicated, innermost last.
+	adjudicating []godip.Province
+}
+
+func (self *resolver) Explanation() *godip.Explanation {
+	if len(self.explaining) == 0 {
+		return nil
+	}
+	return self.explaining[len(self.explaining)-1]
 }
 
 func (self *resolver) adjudicate(prov godip.Province) (err error) {
-	godip.Logf("Adj(%v)", prov)
-	godip.Indent("  ")
+	self.Logger().Logf("Adj(%v)", prov)
+	self.Logger().Indent("  ")
 	order, _, found := self.State.Order(prov)
 	if !found {
 		return fmt.Errorf("No order for %v found", prov)
 	}
+	explanation := &godip.Explanation{Province: prov}
+	self.explaining = append(self.explaining, explanation)
+	self.adjudicating = append(self.adjudicating, prov)
 	err = order.(godip.Adjudicator).Adjudicate(self)
-	godip.DeIndent()
+	self.adjudicating = self.adjudicating[:len(self.adjudicating)-1]
+	self.explaining = self.explaining[:len(self.explaining)-1]
+	self.State.explanations[prov] = explanation
+	self.Logger().DeIndent()
 	if err == nil {
-		godip.Logf("%v: Success", prov)
+		self.Logger().Logf("%v: Success", prov)
 	} else {
-		godip.Logf("%v: Failure: %v", prov, err)
+		self.Logger().Logf("%v: Failure: %v", prov, err)
 	}
 	return
 }
 
 func (self *resolver) Resolve(prov godip.Province) (err error) {
-	godip.Logf("Res(%v) (deps %v)", prov, self.deps)
-	godip.Indent("  ")
+	self.Logger().Logf("Res(%v) (deps %v)", prov, self.deps)
+	self.Logger().Indent("  ")
+	if self.State.dependencies != nil && len(self.adjudicating) > 0 {
+		self.State.dependencies.addDependency(self.adjudicating[len(self.adjudicating)-1], prov)
+	}
 	var ok bool
 	if err, ok = self.State.resolutions[prov]; !ok {
 		if err, ok = self.guesses[prov]; !ok {
 			if self.resolving[prov] {
-				godip.Logf("Already resolving %v, making negative guess", prov)
+				self.Logger().Logf("Already resolving %v, making negative guess", prov)
 				err = fmt.Errorf("Negative guess")
 				self.guesses[prov] = err
 				self.deps = append(self.deps, prov)
+				if self.State.dependencies != nil {
+					self.State.dependencies.node(prov).Guessed = true
+				}
 			} else {
 				self.resolving[prov] = true
 				n_guesses := len(self.guesses)
 				err = self.adjudicate(prov)
 				delete(self.resolving, prov)
 				if _, ok = self.guesses[prov]; ok {
-					godip.Logf("Guess made for %v, changing guess to positive", prov)
+					self.Logger().Logf("Guess made for %v, changing guess to positive", prov)
 					self.guesses[prov] = nil
 					secondErr := self.adjudicate(prov)
 					delete(self.guesses, prov)
 					if (err == nil) != (secondErr == nil) {
-						godip.Logf("Calling backup rule with %v", self.deps)
-						if err = self.State.backupRule(self, self.deps); err != nil {
+						self.Logger().Logf("Calling backup rule with %v", self.deps)
+						if self.State.dependencies != nil {
+							self.State.dependencies.addCycle(self.deps)
+						}
+						if err = self.State.callBackupRule(self, self.deps); err != nil {
 							return
 						}
+						for _, dep := range self.deps {
+							if _, found := self.State.resolutions[dep]; found {
+								self.State.explain(dep).BackupRule = true
+							}
+						}
 						self.deps = nil
 						err = self.Resolve(prov)
 					} else {
-						godip.Logf("Only one consistent result, returning %+v", err)
+						self.Logger().Logf("Only one consistent result, returning %+v", err)
 					}
 				} else if len(self.guesses) != n_guesses {
-					godip.Logf("Made new guess, adding %v to deps", prov)
+					self.Logger().Logf("Made new guess, adding %v to deps", prov)
 					self.deps = append(self.deps, prov)
 				}
 			}
 		} else {
-			godip.Logf("Guessed")
+			self.Logger().Logf("Guessed")
 		}
 		if len(self.guesses) == 0 {
-			godip.Logf("No guessing, resolving %v", prov)
+			self.Logger().Logf("No guessing, resolving %v", prov)
 			self.State.resolutions[prov] = err
 		}
 	} else {
-		godip.Logf("Resolved")
+		self.Logger().Logf("Resolved")
 	}
-	godip.DeIndent()
+	self.Logger().DeIndent()
 	if err == nil {
-		godip.Logf("%v: Success (deps %v)", prov, self.deps)
+		self.Logger().Logf("%v: Success (deps %v)", prov, self.deps)
 	} else {
-		godip.Logf("%v: Failure: %v (deps %v)", prov, err, self.deps)
+		self.Logger().Logf("%v: Failure: %v (deps %v)", prov, err, self.deps)
 	}
 	return
 }
@@ -2,6 +2,8 @@ package state
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/zond/godip"
@@ -23,7 +25,9 @@ func New(graph godip.Graph, phase godip.Phase, backupRule godip.BackupRule, flag
 		profile:            make(map[string]time.Duration),
 		profileCounts:      make(map[string]int),
 		memoizedProvSlices: make(map[string][]godip.Province),
+		explanations:       make(map[godip.Province]*godip.Explanation),
 		flags:              flags,
+		lock:               &sync.Mutex{},
 	}
 }
 
@@ -42,7 +46,7 @@ func (self *movement) prepare(s *State) (err error) {
 	} else {
 		s.RemoveUnit(self.src)
 	}
-	godip.Logf("Lifted %v from %v", self.unit, self.src)
+	s.Logger().Logf("Lifted %v from %v", self.unit, self.src)
 	return
 }
 
@@ -55,12 +59,12 @@ func (self *movement) execute(s *State) (err error) {
 		if self.preventRetreat {
 			s.SetDislodger(self.src, prov)
 		}
-		godip.Logf("Dislodged %v from %v", dislodged, self.dst)
+		s.Logger().Logf("Dislodged %v from %v", dislodged, self.dst)
 	}
 	if err = s.SetUnit(self.dst, self.unit); err != nil {
 		return
 	}
-	godip.Logf("Dropped %v in %v", self.unit, self.dst)
+	s.Logger().Logf("Dropped %v in %v", self.unit, self.dst)
 	return
 }
 
@@ -75,6 +79,7 @@ type State struct {
 	backupRule         godip.BackupRule
 	neutralOrders      func(State) map[godip.Province]godip.Adjudicator
 	resolutions        map[godip.Province]error
+	explanations       map[godip.Province]*godip.Explanation
 	dislodgers         map[godip.Province]godip.Province
 	forceDisbands      map[godip.Province]bool
 	movements          []*movement
@@ -83,20 +88,33 @@ type State struct {
 	profileCounts      map[string]int
 	memoizedProvSlices map[string][]godip.Province
 	flags              map[godip.Flag]bool
+	rules              godip.Rules
+	recordDependencies bool
+	dependencies       *Dependencies
+	logger             godip.Logger
+	// lock protects profile, profileCounts and memoizedProvSlices when options are computed in parallel.
+	lock *sync.Mutex
 }
 
 func (self *State) Profile(a string, t time.Time) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	self.profile[a] += time.Now().Sub(t)
 	self.profileCounts[a] += 1
 }
 
 func (self *State) MemoizeProvSlice(key string, f func() []godip.Province) []godip.Province {
+	self.lock.Lock()
 	old, found := self.memoizedProvSlices[key]
+	self.lock.Unlock()
 	if found {
 		return old
 	}
+	// f is called without holding the lock, since it might memoize other slices.
 	neu := f()
+	self.lock.Lock()
 	self.memoizedProvSlices[key] = neu
+	self.lock.Unlock()
 	return neu
 }
 
@@ -104,6 +122,21 @@ func (self *State) Flags() map[godip.Flag]bool {
 	return self.flags
 }
 
+// Logger returns the logger of this state, which is godip.GlobalLogger unless another one was set using SetLogger.
+func (self *State) Logger() godip.Logger {
+	if self.logger == nil {
+		return godip.GlobalLogger
+	}
+	return self.logger
+}
+
+// SetLogger makes this state log to logger instead of godip.GlobalLogger, which allows tracing a single game without
+// enabling godip.Debug for all of them.
+func (self *State) SetLogger(logger godip.Logger) *State {
+	self.logger = logger
+	return self
+}
+
 func (self *State) GetProfile() (map[string]time.Duration, map[string]int) {
 	return self.profile, self.profileCounts
 }
@@ -120,10 +153,44 @@ func (self *State) Graph() godip.Graph {
 	return self.graph
 }
 
+/*
+optionProvinces returns the provinces where nation can have options: the provinces of its units and dislodged units,
+and every coast of its supply centers, since builds are given per coast.
+
+Order types must not have options anywhere else, or Options will miss them.
+*/
+func (self *State) optionProvinces(nation godip.Nation) []godip.Province {
+	found := map[godip.Province]bool{}
+	for prov, unit := range self.units {
+		if unit.Nation == nation {
+			found[prov.Super()] = true
+		}
+	}
+	for prov, unit := range self.dislodgeds {
+		if unit.Nation == nation {
+			found[prov.Super()] = true
+		}
+	}
+	for prov, owner := range self.supplyCenters {
+		if owner == nation {
+			for _, coast := range self.graph.Coasts(prov) {
+				found[coast] = true
+			}
+		}
+	}
+	result := make([]godip.Province, 0, len(found))
+	for prov := range found {
+		if self.graph.Has(prov) {
+			result = append(result, prov)
+		}
+	}
+	return result
+}
+
 func (self *State) Options(orders []godip.Order, nation godip.Nation) (result godip.Options) {
 	defer self.Profile("Options", time.Now())
 	result = godip.Options{}
-	for _, prov := range self.graph.Provinces() {
+	for _, prov := range self.optionProvinces(nation) {
 		for _, order := range orders {
 			before := time.Now()
 			opts := order.Options(self, nation, prov)
@@ -141,6 +208,31 @@ func (self *State) Options(orders []godip.Order, nation godip.Nation) (result go
 	return
 }
 
+/*
+AllOptions returns the options of every nation in the graph, computed in parallel.
+
+This is faster than calling Phase().Options for one nation at a time, since the nations share the possible moves and
+convoy end points memoized by the state.
+*/
+func (self *State) AllOptions() map[godip.Nation]godip.Options {
+	nations := self.graph.Nations()
+	options := make([]godip.Options, len(nations))
+	wg := sync.WaitGroup{}
+	for i, nation := range nations {
+		wg.Add(1)
+		go func(i int, nation godip.Nation) {
+			defer wg.Done()
+			options[i] = self.phase.Options(self, nation)
+		}(i, nation)
+	}
+	wg.Wait()
+	result := make(map[godip.Nation]godip.Options, len(nations))
+	for i, nation := range nations {
+		result[nation] = options[i]
+	}
+	return result
+}
+
 func (self *State) Find(filter godip.StateFilter) (provinces []godip.Province, orders []godip.Order, units []*godip.Unit) {
 	visitedProvinces := make(map[godip.Province]bool)
 	for prov, unit := range self.units {
@@ -178,11 +270,16 @@ func (self *State) Next() (err error) {
 	   Sanitize orders.
 	*/
 	self.resolutions = make(map[godip.Province]error)
+	self.explanations = make(map[godip.Province]*godip.Explanation)
+	self.dependencies = nil
+	if self.recordDependencies {
+		self.dependencies = newDependencies()
+	}
 	for prov, order := range self.orders {
 		if _, err := order.Validate(self); err != nil {
 			self.resolutions[prov] = err
 			delete(self.orders, prov)
-			godip.Logf("Deleted %v due to %v", prov, err)
+			self.Logger().Logf("Deleted %v due to %v", prov, err)
 		}
 	}
 
@@ -218,12 +315,34 @@ func (self *State) Next() (err error) {
 	self.previouslyAppliedOrders = self.orders
 
 	/*
-	   Adjudicate orders.
+	   Adjudicate orders, in a fixed order since the cycles found depend on where the resolution starts, and backup
+	   rules like AllHold make the result depend on the cycles.
 	*/
-	for prov, _ := range self.orders {
+	provs := make([]godip.Province, 0, len(self.orders))
+	for prov := range self.orders {
+		provs = append(provs, prov)
+	}
+	sort.Slice(provs, func(i, j int) bool {
+		return provs[i] < provs[j]
+	})
+	for _, prov := range provs {
 		err := self.resolver().Resolve(prov)
 		self.resolutions[prov] = err
 	}
+	for prov, err := range self.resolutions {
+		self.explain(prov).Resolution = err
+	}
+	self.explainConvoyPathCandidates()
+	if self.dependencies != nil {
+		for prov, order := range self.orders {
+			node := self.dependencies.node(prov)
+			node.Order = fmt.Sprint(order)
+			node.Resolution = "OK"
+			if err := self.resolutions[prov]; err != nil {
+				node.Resolution = err.Error()
+			}
+		}
+	}
 
 	/*
 	   Execute orders.
@@ -309,7 +428,85 @@ func (self *State) SetDislodgeds(dislodgeds map[godip.Province]godip.Unit) (err
 func (self *State) SetSupplyCenters(supplyCenters map[godip.Province]godip.Nation) *State {
 	self.memoizedProvSlices = map[string][]godip.Province{}
 
-	self.supplyCenters = supplyCenters
+	self.supplyCenters = copySupplyCenters(supplyCenters)
+	return self
+}
+
+func (self *State) SetForceDisbands(forceDisbands map[godip.Province]bool) *State {
+	self.forceDisbands = make(map[godip.Province]bool, len(forceDisbands))
+	for prov, disband := range forceDisbands {
+		self.forceDisbands[prov] = disband
+	}
+	return self
+}
+
+func (self *State) SetPreviouslyAppliedOrders(orders map[godip.Province]godip.Adjudicator) *State {
+	self.previouslyAppliedOrders = copyOrders(orders)
+	return self
+}
+
+// SetResolutions replaces the resolutions of the last call to state.Next(), e.g. when restoring a serialized state.
+func (self *State) SetResolutions(resolutions map[godip.Province]error) *State {
+	self.resolutions = make(map[godip.Province]error, len(resolutions))
+	for prov, err := range resolutions {
+		self.resolutions[prov] = err
+	}
+	return self
+}
+
+// SetExplanations replaces the explanations of the last call to state.Next(), e.g. when restoring a serialized state.
+func (self *State) SetExplanations(explanations map[godip.Province]*godip.Explanation) *State {
+	self.explanations = make(map[godip.Province]*godip.Explanation, len(explanations))
+	for prov, explanation := range explanations {
+		self.explanations[prov] = explanation.Copy()
+	}
+	return self
+}
+
+func (self *State) SetFlags(flags map[godip.Flag]bool) *State {
+	self.flags = nil
+	if flags != nil {
+		self.flags = make(map[godip.Flag]bool, len(flags))
+		for flag, value := range flags {
+			self.flags[flag] = value
+		}
+	}
+	return self
+}
+
+// Rules returns the choices made for the issues the DATC leaves to the judge.
+func (self *State) Rules() godip.Rules {
+	return self.rules
+}
+
+// RecordDependencies makes the following calls to Next record how the resolutions of the orders depended on each
+// other, which is returned by Dependencies.
+func (self *State) RecordDependencies(record bool) *State {
+	self.recordDependencies = record
+	return self
+}
+
+// Dependencies returns how the resolutions of the orders depended on each other during the last call to Next, or nil
+// if that call wasn't recorded.
+func (self *State) Dependencies() *Dependencies {
+	return self.dependencies
+}
+
+// callBackupRule calls the backup rule selected by the rules of this state, or the one of the variant.
+func (self *State) callBackupRule(s godip.State, deps []godip.Province) error {
+	if self.rules.BackupRule == "" {
+		return self.backupRule(s, deps)
+	}
+	backupRule, found := godip.BackupRules[self.rules.BackupRule]
+	if !found {
+		return fmt.Errorf("Unknown backup rule %#v", self.rules.BackupRule)
+	}
+	return backupRule(s, deps)
+}
+
+// SetRules replaces the choices made for the issues the DATC leaves to the judge.
+func (self *State) SetRules(rules godip.Rules) *State {
+	self.rules = rules
 	return self
 }
 
@@ -329,12 +526,101 @@ func (self *State) Load(
 	bounces map[godip.Province]map[godip.Province]bool,
 	orders map[godip.Province]godip.Adjudicator) *State {
 
+	self.memoizedProvSlices = map[string][]godip.Province{}
+
 	self.units, self.supplyCenters, self.dislodgeds, self.dislodgers, self.bounces, self.orders =
-		units, supplyCenters, dislodgeds, dislodgers, bounces, orders
+		copyUnits(units), copySupplyCenters(supplyCenters), copyUnits(dislodgeds), copyDislodgers(dislodgers), copyBounces(bounces), copyOrders(orders)
 
 	return self
 }
 
+// Clone returns a deep copy of this state, including copies of its orders, sharing only the immutable graph, phase,
+// backup rule, neutral orders and logger. Mutating the clone, or calling Next on it, never affects this state.
+func (self *State) Clone() *State {
+	flags := make(map[godip.Flag]bool, len(self.flags))
+	for flag, value := range self.flags {
+		flags[flag] = value
+	}
+	result := New(self.graph, self.phase, self.backupRule, flags, self.neutralOrders)
+	result.logger = self.logger
+	result.rules = self.rules
+	result.recordDependencies = self.recordDependencies
+	result.dependencies = self.dependencies.Copy()
+	result.units = copyUnits(self.units)
+	result.dislodgeds = copyUnits(self.dislodgeds)
+	result.supplyCenters = copySupplyCenters(self.supplyCenters)
+	result.dislodgers = copyDislodgers(self.dislodgers)
+	result.bounces = copyBounces(self.bounces)
+	result.orders = cloneOrders(self.orders)
+	if self.previouslyAppliedOrders != nil {
+		result.previouslyAppliedOrders = cloneOrders(self.previouslyAppliedOrders)
+	}
+	if self.resolutions != nil {
+		result.resolutions = make(map[godip.Province]error, len(self.resolutions))
+		for prov, err := range self.resolutions {
+			result.resolutions[prov] = err
+		}
+	}
+	for prov, disband := range self.forceDisbands {
+		result.forceDisbands[prov] = disband
+	}
+	for prov, explanation := range self.explanations {
+		result.explanations[prov] = explanation.Copy()
+	}
+	return result
+}
+
+func copyUnits(units map[godip.Province]godip.Unit) map[godip.Province]godip.Unit {
+	result := make(map[godip.Province]godip.Unit, len(units))
+	for prov, unit := range units {
+		result[prov] = unit
+	}
+	return result
+}
+
+func copySupplyCenters(supplyCenters map[godip.Province]godip.Nation) map[godip.Province]godip.Nation {
+	result := make(map[godip.Province]godip.Nation, len(supplyCenters))
+	for prov, nation := range supplyCenters {
+		result[prov] = nation
+	}
+	return result
+}
+
+func copyDislodgers(dislodgers map[godip.Province]godip.Province) map[godip.Province]godip.Province {
+	result := make(map[godip.Province]godip.Province, len(dislodgers))
+	for attacker, victim := range dislodgers {
+		result[attacker] = victim
+	}
+	return result
+}
+
+func copyBounces(bounces map[godip.Province]map[godip.Province]bool) map[godip.Province]map[godip.Province]bool {
+	result := make(map[godip.Province]map[godip.Province]bool, len(bounces))
+	for dst, sources := range bounces {
+		result[dst] = make(map[godip.Province]bool, len(sources))
+		for src, bounce := range sources {
+			result[dst][src] = bounce
+		}
+	}
+	return result
+}
+
+func copyOrders(orders map[godip.Province]godip.Adjudicator) map[godip.Province]godip.Adjudicator {
+	result := make(map[godip.Province]godip.Adjudicator, len(orders))
+	for prov, order := range orders {
+		result[prov] = order
+	}
+	return result
+}
+
+func cloneOrders(orders map[godip.Province]godip.Adjudicator) map[godip.Province]godip.Adjudicator {
+	result := make(map[godip.Province]godip.Adjudicator, len(orders))
+	for prov, order := range orders {
+		result[prov] = order.Clone()
+	}
+	return result
+}
+
 // Singular setters
 
 func (self *State) SetDislodger(attacker, victim godip.Province) {
@@ -420,6 +706,39 @@ func (self *State) Resolutions() map[godip.Province]error {
 	return self.resolutions
 }
 
+// explainConvoyPathCandidates records the failed convoys along the considered convoy paths of the explanations, once
+// all convoys are resolved.
+func (self *State) explainConvoyPathCandidates() {
+	for _, explanation := range self.explanations {
+		for i := range explanation.ConvoyPathCandidates {
+			candidate := &explanation.ConvoyPathCandidates[i]
+			candidate.Failures = nil
+			for _, prov := range candidate.Path[:len(candidate.Path)-1] {
+				if err := self.resolutions[prov]; err != nil {
+					if candidate.Failures == nil {
+						candidate.Failures = map[godip.Province]error{}
+					}
+					candidate.Failures[prov] = err
+				}
+			}
+		}
+	}
+}
+
+// Explanations contains an explanation for each resolution of the last call to state.Next().
+func (self *State) Explanations() map[godip.Province]*godip.Explanation {
+	return self.explanations
+}
+
+func (self *State) explain(prov godip.Province) *godip.Explanation {
+	explanation, found := self.explanations[prov]
+	if !found {
+		explanation = &godip.Explanation{Province: prov}
+		self.explanations[prov] = explanation
+	}
+	return explanation
+}
+
 func (self *State) SupplyCenters() map[godip.Province]godip.Nation {
 	return self.supplyCenters
 }
@@ -568,7 +887,7 @@ func (self *State) Retreat(src, dst godip.Province) (err error) {
 		if err = self.SetUnit(dst, unit); err != nil {
 			return
 		}
-		godip.Logf("Moving dislodged %v from %v to %v", unit, src, dst)
+		self.Logger().Logf("Moving dislodged %v from %v to %v", unit, src, dst)
 	}
 	return
 }
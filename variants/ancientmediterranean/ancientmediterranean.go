@@ -30,6 +30,7 @@ var AncientMediterraneanVariant = common.Variant{
 	Seasons:    classical.Seasons,
 	UnitTypes:  classical.UnitTypes,
 	SoloWinner: common.SCCountWinner(18),
+	Victory:    common.UntilSolo,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/ancientmediterraneanmap.svg")
 	},
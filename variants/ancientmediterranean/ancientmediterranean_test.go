@@ -11,10 +11,6 @@ import (
 	tst "github.com/zond/godip/variants/testing"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func startState(t *testing.T) *state.State {
 	judge, err := AncientMediterraneanStart()
 	if err != nil {
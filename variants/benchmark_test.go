@@ -0,0 +1,122 @@
+package variants
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/gamerecord"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+
+	vrt "github.com/zond/godip/variants/common"
+)
+
+// maxBenchmarkPositions is the max number of positions of each variant to benchmark, picked evenly from its games.
+const maxBenchmarkPositions = 20
+
+/*
+benchmarkPositions returns the positions of variant to benchmark: phases of the recorded games in
+variants/[variant name without spaces, lowercase]/games/game_*.txt, or the start of the variant if it has no games.
+*/
+func benchmarkPositions(b testing.TB, variant vrt.Variant) []*state.State {
+	files, err := filepath.Glob(filepath.Join(strings.ToLower(strings.ReplaceAll(variant.Name, " ", "")), "games", "game_*.txt"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	sort.Strings(files)
+	all := []*state.State{}
+	for _, file := range files {
+		record, err := gamerecord.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		positions, err := record.States(variant)
+		if err != nil {
+			b.Fatal(err)
+		}
+		all = append(all, positions...)
+	}
+	if len(all) == 0 {
+		start, err := variant.Start()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return []*state.State{start}
+	}
+	if len(all) <= maxBenchmarkPositions {
+		return all
+	}
+	result := make([]*state.State, 0, maxBenchmarkPositions)
+	for i := 0; i < maxBenchmarkPositions; i++ {
+		result = append(result, all[i*len(all)/maxBenchmarkPositions])
+	}
+	return result
+}
+
+// benchmarkVariants runs f as a sub benchmark for each variant, with fresh clones of its positions for each iteration,
+// since states memoize what they calculate.
+func benchmarkVariants(b *testing.B, f func(b *testing.B, variant vrt.Variant, s *state.State)) {
+	for _, variant := range OrderedVariants {
+		variant := variant
+		b.Run(strings.ReplaceAll(variant.Name, " ", ""), func(b *testing.B) {
+			positions := benchmarkPositions(b, variant)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, position := range positions {
+					b.StopTimer()
+					s := position.Clone()
+					b.StartTimer()
+					f(b, variant, s)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNext(b *testing.B) {
+	benchmarkVariants(b, func(b *testing.B, variant vrt.Variant, s *state.State) {
+		if err := s.Next(); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkOptions(b *testing.B) {
+	benchmarkVariants(b, func(b *testing.B, variant vrt.Variant, s *state.State) {
+		for _, nation := range variant.Nations {
+			s.Phase().Options(s, nation)
+		}
+	})
+}
+
+func BenchmarkAllOptions(b *testing.B) {
+	benchmarkVariants(b, func(b *testing.B, variant vrt.Variant, s *state.State) {
+		s.AllOptions()
+	})
+}
+
+func BenchmarkConvoyPathFinder(b *testing.B) {
+	benchmarkVariants(b, func(b *testing.B, variant vrt.Variant, s *state.State) {
+		for src, unit := range s.Units() {
+			if unit.Type != godip.Army {
+				continue
+			}
+			for _, dst := range s.Graph().Provinces() {
+				if dst.Super() != dst || dst.Super() == src.Super() || !s.Graph().Flags(dst)[godip.Land] {
+					continue
+				}
+				(orders.ConvoyPathFinder{
+					ConvoyPathFilter: orders.ConvoyPathFilter{
+						Validator:              s,
+						Source:                 src,
+						Destination:            dst,
+						MinLengthAtDestination: 1,
+					},
+				}).Any()
+			}
+		}
+	})
+}
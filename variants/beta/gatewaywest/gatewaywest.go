@@ -49,6 +49,7 @@ var GatewayWestVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(18),
+	Victory:           common.UntilSolo,
 	SoloSCCount:       func(*state.State) int { return 18 },
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
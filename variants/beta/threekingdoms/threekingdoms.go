@@ -52,6 +52,7 @@ var ThreeKingdomsVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(9),
+	Victory:           common.UntilSolo,
 	SoloSCCount:       func(*state.State) int { return 9 },
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
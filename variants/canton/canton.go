@@ -102,6 +102,7 @@ var CantonVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(19),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/cantonmap.svg")
@@ -1,8 +1,6 @@
 package classical
 
 import (
-	"fmt"
-
 	"github.com/zond/godip"
 	"github.com/zond/godip/orders"
 	"github.com/zond/godip/phase"
@@ -193,6 +191,7 @@ var ClassicalVariant = common.Variant{
 	Seasons:    Seasons,
 	UnitTypes:  UnitTypes,
 	SoloWinner: common.SCCountWinner(18),
+	Victory:    common.UntilSolo,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/map.svg")
 	},
@@ -222,37 +221,10 @@ func Start() (result *state.State, err error) {
 	return
 }
 
+// BackupRule is the backup rule of the classical variant, and of most other variants: the DATC preferred
+// godip.SzykmanBackupRule.
 func BackupRule(state godip.State, deps []godip.Province) (err error) {
-	only_moves := true
-	convoys := false
-	for _, prov := range deps {
-		if order, _, ok := state.Order(prov); ok {
-			if order.Type() != godip.Move {
-				only_moves = false
-			}
-			if order.Type() == godip.Convoy {
-				convoys = true
-			}
-		}
-	}
-
-	if only_moves {
-		for _, prov := range deps {
-			state.SetResolution(prov, nil)
-		}
-		return
-	}
-	if convoys {
-		for _, prov := range deps {
-			if order, _, ok := state.Order(prov); ok && order.Type() == godip.Convoy {
-				state.SetResolution(prov, godip.ErrConvoyParadox)
-			}
-		}
-		return
-	}
-
-	err = fmt.Errorf("Unknown circular dependency between %v", deps)
-	return
+	return godip.SzykmanBackupRule(state, deps)
 }
 
 var provinceLongNames = map[godip.Province]string{
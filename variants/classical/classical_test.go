@@ -1,7 +1,11 @@
 package classical
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -15,10 +19,6 @@ import (
 	tst "github.com/zond/godip/variants/testing"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func startState(t *testing.T) *state.State {
 	judge, err := Start()
 	if err != nil {
@@ -159,7 +159,7 @@ func TestMoveAdjudication(t *testing.T) {
 	tst.AssertMove(t, startState(t), "mid", "nat", false)
 }
 
-func testDATC(t *testing.T, statePair *datc.StatePair) {
+func testDATC(t *testing.T, statePair *datc.StatePair, rules godip.Rules) {
 	var s *state.State
 	if statePair.Before.Phase == nil {
 		s = Blank(NewPhase(
@@ -170,6 +170,12 @@ func testDATC(t *testing.T, statePair *datc.StatePair) {
 	} else {
 		s = Blank(statePair.Before.Phase)
 	}
+	log := &bytes.Buffer{}
+	s.SetLogger(godip.NewLogger(func(line string) {
+		fmt.Fprintln(log, line)
+	}))
+	s.Logger().Logf("Running %v", statePair.Case)
+	s.SetRules(rules)
 	s.SetUnits(statePair.Before.Units)
 	s.SetDislodgeds(statePair.Before.Dislodgeds)
 	s.SetSupplyCenters(statePair.Before.SCs)
@@ -206,44 +212,15 @@ func testDATC(t *testing.T, statePair *datc.StatePair) {
 			s.SetDislodger(order.Order.Targets()[0], order.Order.Targets()[1])
 		}
 	}
+	errs := statePair.CheckUnordered(s)
 	s.Next()
-	err := false
-	for prov, unit := range statePair.After.Units {
-		if found, ok := s.Units()[prov]; ok {
-			if !found.Equal(unit) {
-				err = true
-				t.Errorf("%v: Expected %v in %v, but found %v", statePair.Case, unit, prov, found)
-			}
-		} else {
-			err = true
-			t.Errorf("%v: Expected %v in %v, but found nothing", statePair.Case, unit, prov)
-		}
-	}
-	for prov, unit := range statePair.After.Dislodgeds {
-		if found, ok := s.Dislodgeds()[prov]; ok {
-			if !found.Equal(unit) {
-				err = true
-				t.Errorf("%v: Expected %v dislodged in %v, but found %v", statePair.Case, unit, prov, found)
-			}
-		} else {
-			err = true
-			t.Errorf("%v: Expected %v dislodged in %v, but found nothing", statePair.Case, unit, prov)
-		}
-	}
-	for prov, unit := range s.Units() {
-		if _, ok := statePair.After.Units[prov]; !ok {
-			err = true
-			t.Errorf("%v: Expected %v to be empty, but found %v", statePair.Case, prov, unit)
-		}
-	}
-	for prov, unit := range s.Dislodgeds() {
-		if _, ok := statePair.After.Dislodgeds[prov]; !ok {
-			err = true
-			t.Errorf("%v: Expected %v to be empty of dislodged units, but found %v", statePair.Case, prov, unit)
-		}
+	errs = append(errs, statePair.Check(s)...)
+	for _, e := range errs {
+		t.Errorf("%v: %v", statePair.Case, e)
 	}
+	err := len(errs) > 0
 	if err {
-		godip.DumpLog()
+		t.Log(log.String())
 		t.Errorf("%v: ### Units:", statePair.Case)
 		for prov, unit := range statePair.Before.Units {
 			t.Errorf("%v: %v %v", statePair.Case, prov, unit)
@@ -272,34 +249,158 @@ func testDATC(t *testing.T, statePair *datc.StatePair) {
 	}
 }
 
-func assertDATC(t *testing.T, file string) {
+var datcParser = datc.Parser{
+	Variant:        "Standard",
+	OrderParser:    DATCOrder,
+	PhaseParser:    DATCPhase,
+	NationParser:   DATCNation,
+	UnitTypeParser: DATCUnitType,
+	ProvinceParser: DATCProvince,
+}
+
+func assertDATC(t *testing.T, file string, rules godip.Rules) {
+	assertDATCExcept(t, file, rules, nil)
+}
+
+// assertDATCExcept runs the cases in file, except the ones in skip, with rules.
+func assertDATCExcept(t *testing.T, file string, rules godip.Rules, skip map[string]bool) {
 	in, err := os.Open(file)
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
-	parser := datc.Parser{
-		Variant:        "Standard",
-		OrderParser:    DATCOrder,
-		PhaseParser:    DATCPhase,
-		NationParser:   DATCNation,
-		UnitTypeParser: DATCUnitType,
-		ProvinceParser: DATCProvince,
-	}
-	if err := parser.Parse(in, func(statePair *datc.StatePair) {
-		godip.ClearLog()
-		godip.Logf("Running %v", statePair.Case)
-		testDATC(t, statePair)
+	if err := datcParser.Parse(in, func(statePair *datc.StatePair) {
+		if skip[statePair.Case] {
+			return
+		}
+		testDATC(t, statePair, rules)
 	}); err != nil {
 		t.Fatalf("%v", err)
 	}
 }
 
 func TestDATC(t *testing.T) {
-	assertDATC(t, "datc/datc_v2.4_06.txt")
-	assertDATC(t, "datc/diplicity_errors.txt")
-	assertDATC(t, "datc/droidippy_errors.txt")
-	assertDATC(t, "datc/dipai.txt")
-	assertDATC(t, "datc/real.txt")
+	assertDATC(t, "datc/datc_v2.4_06.txt", godip.Rules{})
+	assertDATC(t, "datc/diplicity_errors.txt", godip.Rules{})
+	assertDATC(t, "datc/droidippy_errors.txt", godip.Rules{})
+	assertDATC(t, "datc/dipai.txt", godip.Rules{})
+	assertDATC(t, "datc/real.txt", godip.Rules{})
+}
+
+func TestDATCRules(t *testing.T) {
+	assertDATC(t, "datc/rules/explicit_convoy_to_adjacent.txt", godip.Rules{ExplicitConvoyToAdjacent: true})
+	assertDATC(t, "datc/rules/strict_coasts.txt", godip.Rules{StrictCoasts: true})
+	assertDATC(t, "datc/rules/lenient_coasts.txt", godip.Rules{})
+	assertDATC(t, "datc/rules/reachable_support_coasts.txt", godip.Rules{ReachableSupportCoasts: true})
+}
+
+// paradoxCases are the DATC cases that, depending on the order of adjudication, need the backup rule to resolve a
+// paradox and not only a circular movement. Their results for the other backup rules are in datc/rules.
+var paradoxCases = map[string]bool{
+	"6.F.17 (Pandin's extended paradox)": true,
+	"6.F.18":                             true,
+	"6.F.22":                             true,
+	"6.F.22.extended":                    true,
+	"6.F.23":                             true,
+	"6.F.24":                             true,
+	"6.G.11":                             true,
+	"6.G.11.mod":                         true,
+}
+
+// backupRuleCases are the files with the results of paradoxCases for each backup rule but Szykman, with the case
+// names suffixed by the suffix of the rule.
+var backupRuleCases = map[string]struct {
+	file   string
+	suffix string
+}{
+	"AllHold":          {"datc/rules/all_hold.txt", "all_hold"},
+	"CircularMovement": {"datc/rules/circular_movement.txt", "circular_movement"},
+}
+
+func TestDATCBackupRules(t *testing.T) {
+	assertDATC(t, "datc/datc_v2.4_06.txt", godip.Rules{BackupRule: "Szykman"})
+	for name, cases := range backupRuleCases {
+		rules := godip.Rules{BackupRule: name}
+		assertDATCExcept(t, "datc/datc_v2.4_06.txt", rules, paradoxCases)
+		assertDATC(t, cases.file, rules)
+
+		in, err := os.Open(cases.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]bool{}
+		if err := datcParser.Parse(in, func(statePair *datc.StatePair) {
+			found[statePair.Case] = true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		in.Close()
+		for paradox := range paradoxCases {
+			if name := fmt.Sprintf("%v.%v", strings.Fields(paradox)[0], cases.suffix); !found[name] {
+				t.Errorf("Wanted %v in %v", name, cases.file)
+			}
+		}
+	}
+}
+
+func TestDATCExport(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("par", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("bel", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("mun", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("ruh", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("hol", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("nth", godip.Unit{godip.Fleet, godip.England})
+	judge.SetUnit("lon", godip.Unit{godip.Army, godip.England})
+	judge.SetSC("par", godip.France)
+	judge.SetOrders(map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+		"mun": orders.Move("mun", "bur"),
+		"ruh": orders.Move("ruh", "bel"),
+		"hol": orders.SupportMove("hol", "ruh", "bel"),
+		"nth": orders.Convoy("nth", "lon", "yor"),
+		"lon": orders.Move("lon", "yor").ViaConvoy(),
+	})
+	buf := &bytes.Buffer{}
+	before := judge.Clone()
+	if err := judge.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := datc.Export(buf, "export.movement", before, judge); err != nil {
+		t.Fatal(err)
+	}
+	judge.SetOrder("bel", orders.Move("bel", "bur"))
+	before = judge.Clone()
+	if err := judge.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := datc.Export(buf, "export.retreat", before, judge); err != nil {
+		t.Fatal(err)
+	}
+	for _, wanted := range []string{
+		"PRESTATE_SETPHASE Spring 1901, Movement\n",
+		"\tFrance: A par-bur RESULT ErrBounce:mun\n",
+		"\tEngland: F nth C A lon-yor RESULT OK\n",
+		"\tEngland: A lon-yor via convoy RESULT OK\n",
+		"POSTSTATE_DISLODGED\n\tFrance: A bel\n",
+		"POSTSTATE_SUPPLYCENTER_OWNERS\n\tFrance: A par\nPOSTSTATE_FORCE_DISBANDED\nEND\n",
+		"PRESTATE_RESULTS\n\tSUCCESS: Germany: A ruh-bel\n\tFAILURE: Germany: A mun-bur\n\tFAILURE: France: A par-bur\n",
+		"\tFrance: A bel-bur RESULT ErrIllegalRetreat\n",
+		"POSTSTATE_FORCE_DISBANDED\n\tFrance: A bel\nEND\n",
+	} {
+		if !strings.Contains(buf.String(), wanted) {
+			t.Errorf("Wanted %q in\n%v", wanted, buf.String())
+		}
+	}
+	cases := 0
+	if err := datcParser.Parse(bytes.NewBufferString(buf.String()), func(statePair *datc.StatePair) {
+		cases++
+		testDATC(t, statePair, godip.Rules{})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if cases != 2 {
+		t.Errorf("Wanted 2 cases, got %v", cases)
+	}
 }
 
 func TestConvoyOpts(t *testing.T) {
@@ -903,3 +1004,419 @@ func TestAdjacentConvoyOwnFleetUnnecessaryParticipantDislodged(t *testing.T) {
 		t.Errorf("Wanted failure for wal, got %v", found)
 	}
 }
+
+func assertStrength(t *testing.T, explanation *godip.Explanation, want godip.Strength) {
+	for _, found := range explanation.Strengths {
+		if reflect.DeepEqual(found, want) {
+			return
+		}
+	}
+	t.Errorf("Wanted %+v in the strengths of %v, got %+v", want, explanation.Province, explanation.Strengths)
+}
+
+func TestExplainSupportedAttack(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("par", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("mar", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("mun", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("ruh", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("pie", godip.Unit{godip.Army, godip.Italy})
+	judge.SetOrder("par", orders.Move("par", "bur"))
+	judge.SetOrder("mar", orders.SupportMove("mar", "par", "bur"))
+	judge.SetOrder("mun", orders.Move("mun", "bur"))
+	judge.SetOrder("ruh", orders.SupportMove("ruh", "mun", "bur"))
+	judge.SetOrder("pie", orders.Move("pie", "mar"))
+	judge.Next()
+	explanations := judge.Explanations()
+	if found := explanations["mar"].CutBy; !reflect.DeepEqual(found, []godip.Province{"pie"}) {
+		t.Errorf("Wanted mar to be cut by pie, got %v", found)
+	}
+	if found, ok := explanations["par"].Resolution.(godip.ErrBounce); !ok {
+		t.Errorf("Wanted par to have ErrBounce, got %v", found)
+	}
+	assertStrength(t, explanations["par"], godip.Strength{Type: godip.AttackStrength, Province: "par", Target: "bur", Strength: 1})
+	assertStrength(t, explanations["par"], godip.Strength{Type: godip.PreventStrength, Province: "mun", Target: "bur", Strength: 2, Supports: []godip.Province{"ruh"}})
+	if found := explanations["mun"].Resolution; found != nil {
+		t.Errorf("Wanted mun to succeed, got %v", found)
+	}
+	assertStrength(t, explanations["mun"], godip.Strength{Type: godip.AttackStrength, Province: "mun", Target: "bur", Strength: 2, Supports: []godip.Province{"ruh"}})
+	if found := explanations["pie"].Strengths; len(found) != 2 || found[1].Type != godip.HoldStrength || found[1].Strength != 1 {
+		t.Errorf("Wanted pie to be compared to the hold strength of mar, got %+v", found)
+	}
+	b, err := json.Marshal(explanations["par"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"Resolution":"ErrBounce:mun"`) {
+		t.Errorf("Wanted the JSON of par to contain the resolution, got %s", b)
+	}
+}
+
+func TestExplainConvoyPath(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("lon", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("nth", godip.Unit{godip.Fleet, godip.England})
+	judge.SetOrder("lon", orders.Move("lon", "nwy"))
+	judge.SetOrder("nth", orders.Convoy("nth", "lon", "nwy"))
+	judge.Next()
+	explanation := judge.Explanations()["lon"]
+	if !explanation.Convoyed || !reflect.DeepEqual(explanation.ConvoyPath, []godip.Province{"nth", "nwy"}) {
+		t.Errorf("Wanted lon to be convoyed via nth, got %+v", explanation)
+	}
+}
+
+func TestExplainConvoyPathCandidates(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("lon", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("eng", godip.Unit{godip.Fleet, godip.England})
+	judge.SetUnit("nth", godip.Unit{godip.Fleet, godip.England})
+	judge.SetUnit("bre", godip.Unit{godip.Fleet, godip.France})
+	judge.SetUnit("mid", godip.Unit{godip.Fleet, godip.France})
+	judge.SetOrder("lon", orders.Move("lon", "bel"))
+	judge.SetOrder("eng", orders.Convoy("eng", "lon", "bel"))
+	judge.SetOrder("nth", orders.Convoy("nth", "lon", "bel"))
+	judge.SetOrder("bre", orders.Move("bre", "eng"))
+	judge.SetOrder("mid", orders.SupportMove("mid", "bre", "eng"))
+	judge.Next()
+	explanation := judge.Explanations()["lon"]
+	if explanation.Resolution != nil || !reflect.DeepEqual(explanation.ConvoyPath, []godip.Province{"nth", "bel"}) {
+		t.Errorf("Wanted lon to be convoyed via nth, got %+v", explanation)
+	}
+	want := []godip.ConvoyPathCandidate{
+		{Path: []godip.Province{"eng", "bel"}, Failures: map[godip.Province]error{"eng": godip.ErrConvoyDislodged{Province: "bre"}}},
+		{Path: []godip.Province{"nth", "bel"}},
+	}
+	if !reflect.DeepEqual(explanation.ConvoyPathCandidates, want) {
+		t.Errorf("Wanted the path via eng rejected by the dislodged convoy, got %+v", explanation.ConvoyPathCandidates)
+	}
+	b, err := json.Marshal(explanation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &godip.Explanation{}
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.ConvoyPathCandidates, want) {
+		t.Errorf("Wanted the candidates to survive JSON, got %s", b)
+	}
+
+	// Without the convoy through nth the only candidate is rejected, and the move fails.
+	judge = Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("lon", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("eng", godip.Unit{godip.Fleet, godip.England})
+	judge.SetUnit("bre", godip.Unit{godip.Fleet, godip.France})
+	judge.SetUnit("mid", godip.Unit{godip.Fleet, godip.France})
+	judge.SetOrder("lon", orders.Move("lon", "bel"))
+	judge.SetOrder("eng", orders.Convoy("eng", "lon", "bel"))
+	judge.SetOrder("bre", orders.Move("bre", "eng"))
+	judge.SetOrder("mid", orders.SupportMove("mid", "bre", "eng"))
+	judge.Next()
+	explanation = judge.Explanations()["lon"]
+	if explanation.Resolution != godip.ErrMissingConvoyPath || !reflect.DeepEqual(explanation.ConvoyPathCandidates, want[:1]) {
+		t.Errorf("Wanted lon to fail with the path via eng rejected, got %+v", explanation)
+	}
+}
+
+func TestExplainBackupRule(t *testing.T) {
+	// DATC 6.C.1, a three army circular movement.
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("ank", godip.Unit{godip.Fleet, godip.Turkey})
+	judge.SetUnit("con", godip.Unit{godip.Army, godip.Turkey})
+	judge.SetUnit("smy", godip.Unit{godip.Army, godip.Turkey})
+	judge.SetOrder("ank", orders.Move("ank", "con"))
+	judge.SetOrder("con", orders.Move("con", "smy"))
+	judge.SetOrder("smy", orders.Move("smy", "ank"))
+	judge.Next()
+	for _, prov := range []godip.Province{"ank", "con", "smy"} {
+		if explanation := judge.Explanations()[prov]; explanation.Resolution != nil || !explanation.BackupRule {
+			t.Errorf("Wanted %v to succeed by the backup rule, got %+v", prov, explanation)
+		}
+	}
+}
+
+func TestStateLogger(t *testing.T) {
+	lines := []string{}
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement)).SetLogger(godip.NewLogger(func(line string) {
+		lines = append(lines, line)
+	}))
+	judge.SetUnit("par", godip.Unit{godip.Army, godip.France})
+	judge.SetOrder("par", orders.Move("par", "bur"))
+	judge.Next()
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "Adj(par)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Wanted the state logger to receive the adjudication of par, got %q", lines)
+	}
+}
+
+func TestCloneNext(t *testing.T) {
+	judge := startState(t)
+	judge.SetOrder("par", orders.Move("par", "bur"))
+	judge.SetOrder("mun", orders.Move("mun", "bur"))
+	judge.Next()
+	clone := judge.Clone()
+	clone.SetOrder("bur", orders.Move("bur", "par"))
+	clone.Next()
+	clone.Next()
+	if found := judge.Phase(); found.Season() != godip.Spring || found.Type() != godip.Retreat {
+		t.Errorf("Wanted the original to stay in %v %v, got %v", godip.Spring, godip.Retreat, found)
+	}
+	if _, ok := judge.Resolutions()["par"].(godip.ErrBounce); !ok {
+		t.Errorf("Wanted the original to keep its resolutions, got %v", judge.Resolutions())
+	}
+	if len(judge.Explanations()) == 0 {
+		t.Errorf("Wanted the original to keep its explanations")
+	}
+	tst.AssertUnit(t, judge, "par", godip.Unit{godip.Army, godip.France})
+	tst.AssertUnit(t, judge, "mun", godip.Unit{godip.Army, godip.Germany})
+	if !judge.Bounce("par", "bur") {
+		t.Errorf("Wanted the original to keep the bounce in bur")
+	}
+}
+
+func TestCloneOrders(t *testing.T) {
+	judge := startState(t)
+	judge.SetOrder("par", orders.Move("par", "bur"))
+	clone := judge.Clone()
+	clone.RemoveUnit("par")
+	clone.Next()
+	if order, _, _ := judge.Order("par"); !reflect.DeepEqual(order.Targets(), []godip.Province{"par", "bur"}) {
+		t.Errorf("Wanted the original to keep its order to par-bur, got %v", order.Targets())
+	}
+	judge.Next()
+	if err := judge.Resolutions()["par"]; err != nil {
+		t.Errorf("Wanted par-bur to succeed in the original, got %v", err)
+	}
+	tst.AssertUnit(t, judge, "bur", godip.Unit{godip.Army, godip.France})
+}
+
+func TestEncodeDecode(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("bur", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("mun", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("ruh", godip.Unit{godip.Army, godip.Germany})
+	judge.SetUnit("pic", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("bel", godip.Unit{godip.Army, godip.England})
+	judge.SetUnit("gas", godip.Unit{godip.Army, godip.France})
+	judge.SetOrder("gas", orders.Move("gas", "par"))
+	judge.SetOrder("mun", orders.Move("mun", "bur"))
+	judge.SetOrder("ruh", orders.SupportMove("ruh", "mun", "bur"))
+	judge.SetOrder("pic", orders.Move("pic", "par"))
+	judge.SetOrder("bel", orders.Move("bel", "hol"))
+	judge.Next()
+	judge.SetOrder("bur", orders.Disband("bur", time.Now()))
+	judge.SetRules(godip.Rules{StrictCoasts: true})
+	b, err := ClassicalVariant.Encode(judge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ClassicalVariant.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(decoded.Phase()) != fmt.Sprint(judge.Phase()) {
+		t.Errorf("Wanted phase %v, got %v", judge.Phase(), decoded.Phase())
+	}
+	wantedUnits, wantedSCs, wantedDislodgeds, wantedDislodgers, wantedBounces, wantedResolutions := judge.Dump()
+	units, scs, dislodgeds, dislodgers, bounces, resolutions := decoded.Dump()
+	for _, pair := range [][2]interface{}{
+		{wantedUnits, units},
+		{wantedSCs, scs},
+		{wantedDislodgeds, dislodgeds},
+		{wantedDislodgers, dislodgers},
+		{wantedBounces, bounces},
+		{wantedResolutions, resolutions},
+		{judge.Flags(), decoded.Flags()},
+		{judge.Rules(), decoded.Rules()},
+		{judge.ForceDisbands(), decoded.ForceDisbands()},
+		{judge.Explanations(), decoded.Explanations()},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("Wanted %#v, got %#v", pair[0], pair[1])
+		}
+	}
+	if _, ok := resolutions["pic"].(godip.ErrBounce); !ok {
+		t.Errorf("Wanted a typed ErrBounce for pic, got %#v", resolutions["pic"])
+	}
+	if found, wanted := len(decoded.PreviouslyAppliedOrders()), len(judge.PreviouslyAppliedOrders()); found != wanted {
+		t.Errorf("Wanted %v previously applied orders, got %v", wanted, found)
+	}
+	order, _, _ := decoded.Order("bur")
+	wantedOrder, _, _ := judge.Order("bur")
+	if !order.At().Equal(wantedOrder.At()) || order.Type() != godip.Disband {
+		t.Errorf("Wanted %v at %v, got %v at %v", wantedOrder, wantedOrder.At(), order, order.At())
+	}
+	judge.Next()
+	decoded.Next()
+	if !reflect.DeepEqual(judge.Units(), decoded.Units()) {
+		t.Errorf("Wanted %v after adjudicating the decoded state, got %v", judge.Units(), decoded.Units())
+	}
+}
+
+func TestPreview(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Fall, godip.Movement))
+	judge.SetSupplyCenters(start.SupplyCenters())
+	judge.SetUnit("bur", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("mar", godip.Unit{godip.Army, godip.France})
+	judge.SetUnit("ruh", godip.Unit{godip.Army, godip.Germany})
+	judge.SetOrder("bur", orders.Hold("bur"))
+	previews, err := judge.PreviewAll([]map[godip.Province]godip.Adjudicator{
+		{
+			"bur": orders.Move("bur", "mun"),
+			"ruh": orders.Move("ruh", "mun"),
+		},
+		{
+			"bur": orders.Move("bur", "mun"),
+			"mar": orders.Move("mar", "pie"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := previews[0].Resolutions["bur"].(godip.ErrBounce); !ok {
+		t.Errorf("Wanted bur to bounce in the first scenario, got %v", previews[0].Resolutions["bur"])
+	}
+	if len(previews[0].SupplyCenterChanges) != 0 {
+		t.Errorf("Wanted no supply center changes in the first scenario, got %v", previews[0].SupplyCenterChanges)
+	}
+	if found := previews[1].Units["mun"]; found != (godip.Unit{godip.Army, godip.France}) {
+		t.Errorf("Wanted France in mun in the second scenario, got %v", found)
+	}
+	if !reflect.DeepEqual(previews[1].SupplyCenterChanges, map[godip.Province]godip.Nation{"mun": godip.France}) {
+		t.Errorf("Wanted France to take mun in the second scenario, got %v", previews[1].SupplyCenterChanges)
+	}
+	if found := previews[1].Phase.Type(); found != godip.Adjustment {
+		t.Errorf("Wanted the second scenario to end in %v, got %v", godip.Adjustment, found)
+	}
+	if found := judge.Phase().Type(); found != godip.Movement {
+		t.Errorf("Wanted the original to stay in %v, got %v", godip.Movement, found)
+	}
+	tst.AssertUnit(t, judge, "bur", godip.Unit{godip.Army, godip.France})
+	if order, _, _ := judge.Order("bur"); order == nil || order.Type() != godip.Hold {
+		t.Errorf("Wanted the original to keep its orders, got %v", order)
+	}
+	if found := judge.SupplyCenters()["mun"]; found != godip.Germany {
+		t.Errorf("Wanted the original to keep mun German, got %v", found)
+	}
+}
+
+func TestPreviewSharedOrders(t *testing.T) {
+	judge := startState(t)
+	shared := map[godip.Province]godip.Adjudicator{
+		"par": orders.Move("par", "bur"),
+		"stp": orders.Move("stp", "bot"),
+	}
+	previews, err := judge.PreviewAll([]map[godip.Province]godip.Adjudicator{shared, shared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, preview := range previews {
+		if found := preview.Units["bur"]; found != (godip.Unit{godip.Army, godip.France}) {
+			t.Errorf("Wanted France in bur in scenario %v, got %v", index, found)
+		}
+		if found := preview.Units["bot"]; found != (godip.Unit{godip.Fleet, godip.Russia}) {
+			t.Errorf("Wanted Russia in bot in scenario %v, got %v", index, found)
+		}
+	}
+	if targets := shared["stp"].Targets(); !reflect.DeepEqual(targets, []godip.Province{"stp", "bot"}) {
+		t.Errorf("Wanted the shared orders to be left untouched, got %v", targets)
+	}
+}
+
+func TestResult(t *testing.T) {
+	judge := startState(t)
+	result := ClassicalVariant.Result(judge)
+	if result.Ended() || len(result.Survivors) != 7 || len(result.Eliminated) != 0 {
+		t.Errorf("Wanted all nations to survive an unfinished game at the start, got %+v", result)
+	}
+	for prov, unit := range judge.Units() {
+		if unit.Nation == godip.Italy {
+			judge.RemoveUnit(prov)
+		}
+	}
+	for prov, nation := range judge.SupplyCenters() {
+		if nation == godip.Italy {
+			judge.SetSC(prov, godip.Austria)
+		}
+	}
+	result = ClassicalVariant.Result(judge)
+	if !reflect.DeepEqual(result.Eliminated, []godip.Nation{godip.Italy}) || len(result.Survivors) != 6 {
+		t.Errorf("Wanted Italy to be eliminated, got %+v", result)
+	}
+	limited := ClassicalVariant
+	limited.Victory.MaxYear = 1900
+	if result := limited.Result(judge); !result.Draw || !result.Ended() {
+		t.Errorf("Wanted a draw after the last year, got %+v", result)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	judge := Blank(NewPhase(1901, godip.Spring, godip.Movement))
+	judge.SetUnit("ank", godip.Unit{godip.Fleet, godip.Turkey})
+	judge.SetUnit("con", godip.Unit{godip.Army, godip.Turkey})
+	judge.SetUnit("smy", godip.Unit{godip.Army, godip.Turkey})
+	judge.SetUnit("par", godip.Unit{godip.Army, godip.France})
+	judge.SetOrders(map[godip.Province]godip.Adjudicator{
+		"ank": orders.Move("ank", "con"),
+		"con": orders.Move("con", "smy"),
+		"smy": orders.Move("smy", "ank"),
+	})
+	unrecorded := judge.Clone()
+	if err := unrecorded.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if deps := unrecorded.Dependencies(); deps != nil {
+		t.Errorf("Wanted no dependencies unless recording, got %+v", deps)
+	}
+	judge.RecordDependencies(true)
+	if err := judge.Next(); err != nil {
+		t.Fatal(err)
+	}
+	deps := judge.Dependencies()
+	if deps == nil {
+		t.Fatalf("Wanted dependencies to be recorded")
+	}
+	if len(deps.Cycles) != 1 || len(deps.Cycles[0]) != 3 {
+		t.Errorf("Wanted one cycle of three provinces, got %v", deps.Cycles)
+	}
+	for _, prov := range []godip.Province{"ank", "con", "smy"} {
+		if node := deps.Nodes[prov]; node == nil || !node.BackupRule || node.Resolution != "OK" {
+			t.Errorf("Wanted %v to be resolved by the backup rule, got %+v", prov, node)
+		}
+	}
+	if node := deps.Nodes["par"]; node == nil || len(node.DependsOn) != 0 || node.BackupRule || node.Order != "par Hold" {
+		t.Errorf("Wanted par to hold without dependencies, got %+v", node)
+	}
+	buf := &bytes.Buffer{}
+	if err := deps.WriteDOT(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, wanted := range []string{
+		"digraph dependencies {\n",
+		"\t\"ank\" -> \"con\";\n",
+		"\t\"con\" -> \"smy\";\n",
+		"\t\"smy\" -> \"ank\";\n",
+		"\t\"par\" [label=\"par Hold\\nOK\"];\n",
+		"color=red",
+	} {
+		if !strings.Contains(buf.String(), wanted) {
+			t.Errorf("Wanted %q in\n%v", wanted, buf.String())
+		}
+	}
+	b, err := json.Marshal(deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &state.Dependencies{}
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deps, decoded) {
+		t.Errorf("Wanted %+v after JSON round trip, got %+v", deps, decoded)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package classical
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+)
+
+func TestSlogLoggers(t *testing.T) {
+	logs := map[godip.Province]*bytes.Buffer{"par": {}, "vie": {}}
+	destinations := map[godip.Province]godip.Province{"par": "bur", "vie": "tyr"}
+	done := make(chan bool)
+	for prov, log := range logs {
+		handler := slog.NewTextHandler(log, &slog.HandlerOptions{Level: slog.LevelDebug})
+		judge := Blank(NewPhase(1901, godip.Spring, godip.Movement)).SetLogger(godip.SlogLogger(slog.New(handler), slog.LevelDebug))
+		judge.SetUnit(prov, godip.Unit{godip.Army, godip.France})
+		judge.SetOrder(prov, orders.Move(prov, destinations[prov]))
+		go func() {
+			judge.Next()
+			done <- true
+		}()
+	}
+	<-done
+	<-done
+	if !strings.Contains(logs["par"].String(), "Adj(par)") || strings.Contains(logs["par"].String(), "Adj(vie)") {
+		t.Errorf("Wanted only the adjudication of par in its log, got %q", logs["par"].String())
+	}
+	if !strings.Contains(logs["vie"].String(), "Adj(vie)") || strings.Contains(logs["vie"].String(), "Adj(par)") {
+		t.Errorf("Wanted only the adjudication of vie in its log, got %q", logs["vie"].String())
+	}
+}
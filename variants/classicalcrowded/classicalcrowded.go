@@ -66,6 +66,7 @@ var ClassicalCrowdedVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(18),
+	Victory:           common.UntilSolo,
 	SoloSCCount:       func(*state.State) int { return 18 },
 	ProvinceLongNames: classical.ClassicalVariant.ProvinceLongNames,
 	SVGMap: func() ([]byte, error) {
@@ -110,6 +110,7 @@ var ColdWarVariant = common.Variant{
 	Seasons:    classical.Seasons,
 	UnitTypes:  classical.UnitTypes,
 	SoloWinner: common.SCCountWinner(17),
+	Victory:    common.UntilSolo,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/coldwarmap.svg")
 	},
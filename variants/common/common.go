@@ -49,6 +49,8 @@ type Variant struct {
 	SoloWinner func(*state.State) godip.Nation `json:"-"`
 	// Number of SCs necessary for a solo, if possible.
 	SoloSCCount func(*state.State) int `json:"-"`
+	// Victory declares when a game of this variant ends without a solo winner.
+	Victory VictoryConditions
 	// SVG representing the variant map graphics.
 	SVGMap func() ([]byte, error) `json:"-"`
 	// A version for the vector graphics (for use in caching mechanisms).
@@ -71,6 +73,108 @@ type Variant struct {
 	Rules string
 }
 
+// VictoryConditions declares when a game of a variant ends in a draw, since SoloWinner only finds the nation that
+// has won.
+type VictoryConditions struct {
+	// MaxYear is the last year of the game, after which it ends in a draw between the surviving nations unless a nation
+	// has won, or 0 if the game lasts until a nation wins.
+	MaxYear int
+	// TiedLeadersExtraYear is true if a year ending with two or more nations tied for the lead with at least SoloSCCount
+	// supply centers is followed by one extra year, after which the game ends in a draw unless a nation has won.
+	TiedLeadersExtraYear bool
+}
+
+// UntilSolo are the victory conditions of a game that lasts until a nation wins.
+var UntilSolo = VictoryConditions{}
+
+// GameResult describes how a game stands at a given state.
+type GameResult struct {
+	// SoloWinner is the nation that has won the game, or the empty string if no nation has.
+	SoloWinner godip.Nation
+	// Draw is true if the game has ended without a solo winner, because the last year of the variant has passed or
+	// because the extra year after a tie for the lead has.
+	Draw bool
+	// Survivors are the nations that still have units or supply centers.
+	Survivors []godip.Nation
+	// Eliminated are the nations that have neither units nor supply centers.
+	Eliminated []godip.Nation
+}
+
+// Ended returns whether the game is over, either by a solo win or by a draw.
+func (self GameResult) Ended() bool {
+	return self.SoloWinner != "" || self.Draw
+}
+
+// Result returns how a game of this variant stands at s, using SoloWinner to find the winner and Victory to find
+// whether the game has ended in a draw. Rules spanning several years, like TiedLeadersExtraYear, need the earlier
+// states of the game in previous.
+func (self Variant) Result(s *state.State, previous ...*state.State) GameResult {
+	alive := map[godip.Nation]bool{}
+	for _, unit := range s.Units() {
+		alive[unit.Nation] = true
+	}
+	for _, unit := range s.Dislodgeds() {
+		alive[unit.Nation] = true
+	}
+	for _, nation := range s.SupplyCenters() {
+		alive[nation] = true
+	}
+	result := GameResult{}
+	for _, nation := range self.Nations {
+		if alive[nation] {
+			result.Survivors = append(result.Survivors, nation)
+		} else {
+			result.Eliminated = append(result.Eliminated, nation)
+		}
+	}
+	if self.SoloWinner != nil {
+		result.SoloWinner = self.SoloWinner(s)
+	}
+	if result.SoloWinner != "" {
+		return result
+	}
+	if self.Victory.MaxYear != 0 && s.Phase().Year() > self.Victory.MaxYear {
+		result.Draw = true
+	}
+	if self.Victory.TiedLeadersExtraYear && self.SoloSCCount != nil {
+		year := ownershipYear(s)
+		for _, earlier := range append(previous, s) {
+			if ownershipYear(earlier) < year && self.tiedLeaders(earlier) {
+				result.Draw = true
+			}
+		}
+	}
+	return result
+}
+
+// ownershipYear returns the year that ended with the supply center ownership of s, which changes between the fall
+// and the adjustment phase.
+func ownershipYear(s *state.State) int {
+	if s.Phase().Type() == godip.Adjustment {
+		return s.Phase().Year()
+	}
+	return s.Phase().Year() - 1
+}
+
+// tiedLeaders returns whether two or more nations are tied for the most supply centers at s, with enough for a solo.
+func (self Variant) tiedLeaders(s *state.State) bool {
+	scCount := map[godip.Nation]int{}
+	for _, nat := range s.SupplyCenters() {
+		if nat != "" {
+			scCount[nat]++
+		}
+	}
+	highestSCCount, leaders := 0, 0
+	for _, count := range scCount {
+		if count > highestSCCount {
+			highestSCCount, leaders = count, 1
+		} else if count == highestSCCount {
+			leaders++
+		}
+	}
+	return leaders > 1 && highestSCCount >= self.SoloSCCount(s)
+}
+
 // Return a function that declares a solo winner if a nation has more SCs than the given number (and more than any other nation).
 func SCCountWinner(soloSupplyCenters int) func(*state.State) godip.Nation {
 	return func(s *state.State) godip.Nation {
@@ -18,10 +18,6 @@ const (
 	Edinbugh       godip.Province = "Edinburgh"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func TestSCCountWinner_NothingOwned(t *testing.T) {
 	soloFunction := SCCountWinner(2)
 	s := new(state.State)
@@ -0,0 +1,130 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/orders"
+	"github.com/zond/godip/state"
+)
+
+// EncodedState is the serialized form of a state, as produced by Variant.Encode and consumed by Variant.Decode.
+type EncodedState struct {
+	// Variant is the name of the variant of the state.
+	Variant                 string
+	Year                    int
+	Season                  godip.Season
+	Type                    godip.PhaseType
+	Flags                   map[godip.Flag]bool
+	Rules                   godip.Rules
+	Units                   map[godip.Province]godip.Unit
+	SupplyCenters           map[godip.Province]godip.Nation
+	Dislodgeds              map[godip.Province]godip.Unit
+	Dislodgers              map[godip.Province]godip.Province
+	Bounces                 map[godip.Province]map[godip.Province]bool
+	ForceDisbands           map[godip.Province]bool
+	Orders                  map[godip.Province]orders.Encoded
+	PreviouslyAppliedOrders map[godip.Province]orders.Encoded
+	// Resolutions contains "OK" for successful orders, and the Error() string, understood by godip.UnmarshalError, for failed ones.
+	Resolutions  map[godip.Province]string
+	Explanations map[godip.Province]*godip.Explanation `json:",omitempty"`
+}
+
+func encodeOrders(adjudicators map[godip.Province]godip.Adjudicator) (map[godip.Province]orders.Encoded, error) {
+	result := map[godip.Province]orders.Encoded{}
+	for prov, adjudicator := range adjudicators {
+		encoded, err := orders.Encode(adjudicator)
+		if err != nil {
+			return nil, err
+		}
+		result[prov] = encoded
+	}
+	return result, nil
+}
+
+func (self Variant) decodeOrders(encoded map[godip.Province]orders.Encoded) (map[godip.Province]godip.Adjudicator, error) {
+	result := map[godip.Province]godip.Adjudicator{}
+	for prov, order := range encoded {
+		decoded, err := self.Parser.Decode(order)
+		if err != nil {
+			return nil, err
+		}
+		result[prov] = decoded
+	}
+	return result, nil
+}
+
+// Encode returns a JSON representation of s, which must be a state of this variant, that Decode turns back into an
+// identical state including orders, typed resolution errors and explanations.
+func (self Variant) Encode(s *state.State) ([]byte, error) {
+	phase := s.Phase()
+	encoded := EncodedState{
+		Variant:     self.Name,
+		Year:        phase.Year(),
+		Season:      phase.Season(),
+		Type:        phase.Type(),
+		Flags:       s.Flags(),
+		Rules:       s.Rules(),
+		Resolutions: map[godip.Province]string{},
+	}
+	var resolutions map[godip.Province]error
+	encoded.Units, encoded.SupplyCenters, encoded.Dislodgeds, encoded.Dislodgers, encoded.Bounces, resolutions = s.Dump()
+	encoded.ForceDisbands = s.ForceDisbands()
+	for prov, err := range resolutions {
+		if err == nil {
+			encoded.Resolutions[prov] = "OK"
+		} else {
+			encoded.Resolutions[prov] = err.Error()
+		}
+	}
+	if explanations := s.Explanations(); len(explanations) > 0 {
+		encoded.Explanations = explanations
+	}
+	var err error
+	if encoded.Orders, err = encodeOrders(s.Orders()); err != nil {
+		return nil, err
+	}
+	if encoded.PreviouslyAppliedOrders, err = encodeOrders(s.PreviouslyAppliedOrders()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// Decode returns the state represented by data, which must have been produced by Encode of this variant.
+func (self Variant) Decode(data []byte) (*state.State, error) {
+	encoded := &EncodedState{}
+	if err := json.Unmarshal(data, encoded); err != nil {
+		return nil, err
+	}
+	if encoded.Variant != self.Name {
+		return nil, fmt.Errorf("Can't decode a state of %q as %q", encoded.Variant, self.Name)
+	}
+	orders, err := self.decodeOrders(encoded.Orders)
+	if err != nil {
+		return nil, err
+	}
+	previouslyAppliedOrders, err := self.decodeOrders(encoded.PreviouslyAppliedOrders)
+	if err != nil {
+		return nil, err
+	}
+	resolutions := map[godip.Province]error{}
+	for prov, resolution := range encoded.Resolutions {
+		if resolution == "OK" {
+			resolutions[prov] = nil
+		} else {
+			resolutions[prov] = godip.UnmarshalError(resolution)
+		}
+	}
+	for prov, explanation := range encoded.Explanations {
+		explanation.Resolution = resolutions[prov]
+	}
+	return self.Blank(self.Phase(encoded.Year, encoded.Season, encoded.Type)).
+		Load(encoded.Units, encoded.SupplyCenters, encoded.Dislodgeds, encoded.Dislodgers, encoded.Bounces, orders).
+		SetFlags(encoded.Flags).
+		SetRules(encoded.Rules).
+		SetForceDisbands(encoded.ForceDisbands).
+		SetPreviouslyAppliedOrders(previouslyAppliedOrders).
+		SetResolutions(resolutions).
+		SetExplanations(encoded.Explanations), nil
+}
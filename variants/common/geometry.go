@@ -0,0 +1,142 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zond/godip"
+)
+
+// ProvinceGeometry describes where a province is drawn on the map of a variant, in the coordinates of the map svg.
+type ProvinceGeometry struct {
+	// Unit is where a unit in the province is placed.
+	Unit Point
+	// Label is where the name of the province fits best, the point inside the province farthest from its border.
+	Label Point
+	// Bounds is the bounding box of the province.
+	Bounds Rect
+}
+
+var (
+	geometriesLock = sync.Mutex{}
+	geometries     = map[string]map[godip.Province]ProvinceGeometry{}
+)
+
+/*
+ProvinceGeometries returns the geometry of every province of the variant, including the coasts, extracted from the
+map svg.
+
+The outlines of the provinces are the elements in the "provinces" layer with the province as id, and the unit
+placement points are the first points of the "[province]Center" elements. Coasts without own outlines share the
+outline of their super province. Provinces missing an outline or a center element are errors.
+
+The result is cached per variant name and svg version, and must not be modified.
+*/
+func (self Variant) ProvinceGeometries() (map[godip.Province]ProvinceGeometry, error) {
+	key := self.Name + "/" + self.SVGVersion
+	geometriesLock.Lock()
+	defer geometriesLock.Unlock()
+	if result, found := geometries[key]; found {
+		return result, nil
+	}
+	result, err := self.provinceGeometries()
+	if err != nil {
+		return nil, err
+	}
+	geometries[key] = result
+	return result, nil
+}
+
+func (self Variant) provinceGeometries() (map[godip.Province]ProvinceGeometry, error) {
+	if self.SVGMap == nil {
+		return nil, fmt.Errorf("%v has no map", self.Name)
+	}
+	b, err := self.SVGMap()
+	if err != nil {
+		return nil, err
+	}
+	root, byID, err := parseSVG(b)
+	if err != nil {
+		return nil, fmt.Errorf("Unparseable map of %v: %v", self.Name, err)
+	}
+	layer := byID["provinces"]
+	if layer == nil {
+		return nil, fmt.Errorf("%v has no provinces layer in its map", self.Name)
+	}
+	outlines := map[godip.Province][][]Point{}
+	var findOutlines func(*svgNode) error
+	findOutlines = func(node *svgNode) error {
+		for _, child := range node.children {
+			if child.name == "g" {
+				if err := findOutlines(child); err != nil {
+					return err
+				}
+				continue
+			}
+			id := child.attrs["id"]
+			if id == "" {
+				continue
+			}
+			rings, err := shape(child, byID)
+			if err != nil {
+				return fmt.Errorf("Unparseable outline of %v in %v: %v", id, self.Name, err)
+			}
+			if len(rings) > 0 {
+				outlines[godip.Province(id)] = rings
+			}
+		}
+		return nil
+	}
+	if err := findOutlines(layer); err != nil {
+		return nil, err
+	}
+	// The center markers are found by id, since the maps keep them in differently named layers.
+	units := map[godip.Province]Point{}
+	var findCenters func(*svgNode) error
+	findCenters = func(node *svgNode) error {
+		if id := node.attrs["id"]; strings.HasSuffix(id, "Center") {
+			rings, err := shape(node, byID)
+			if err != nil {
+				return fmt.Errorf("Unparseable center %v in %v: %v", id, self.Name, err)
+			}
+			if len(rings) > 0 {
+				prov := godip.Province(strings.TrimSuffix(id, "Center"))
+				if node.name == "path" {
+					units[prov] = rings[0][0]
+				} else {
+					units[prov] = boundsOf(rings).Center()
+				}
+			}
+		}
+		for _, child := range node.children {
+			if err := findCenters(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := findCenters(root); err != nil {
+		return nil, err
+	}
+	result := map[godip.Province]ProvinceGeometry{}
+	for _, prov := range self.Graph().Provinces() {
+		rings, found := outlines[prov]
+		if !found {
+			if rings, found = outlines[prov.Super()]; !found {
+				return nil, fmt.Errorf("%v has no outline for %v in the provinces layer of its map", self.Name, prov)
+			}
+		}
+		unit, found := units[prov]
+		if !found {
+			return nil, fmt.Errorf("%v has no %vCenter element in its map", self.Name, prov)
+		}
+		bounds := boundsOf(rings)
+		result[prov] = ProvinceGeometry{
+			Unit:   unit,
+			Label:  labelPoint(rings, bounds),
+			Bounds: bounds,
+		}
+	}
+	return result, nil
+}
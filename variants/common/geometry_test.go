@@ -0,0 +1,137 @@
+package common
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/graph"
+)
+
+func near(a Point, b Point) bool {
+	return math.Abs(a.X-b.X) < 0.001 && math.Abs(a.Y-b.Y) < 0.001
+}
+
+func TestParsePath(t *testing.T) {
+	rings, err := parsePath("m 10,10 h 10 v 10 H 10 z M30 30l10-10 1e1,1e1z m 5,5 l 1,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rings) != 3 {
+		t.Fatalf("Wanted 3 rings, got %v", rings)
+	}
+	want := []Point{{10, 10}, {20, 10}, {20, 20}, {10, 20}}
+	for i, p := range want {
+		if !near(rings[0][i], p) {
+			t.Errorf("Wanted %v, got %v", want, rings[0])
+		}
+	}
+	if !near(rings[1][2], Point{50, 30}) {
+		t.Errorf("Wanted implicit relative lines after the move, got %v", rings[1])
+	}
+	// A relative move after a close starts at the start of the closed subpath.
+	if !near(rings[2][0], Point{35, 35}) || !near(rings[2][1], Point{36, 36}) {
+		t.Errorf("Wanted a subpath starting at 35,35, got %v", rings[2])
+	}
+
+	rings, err = parsePath("M 0,10 A 10 10 0 01 20,10 q 0,10 -10,10 t -10,-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := boundsOf(rings); !near(bounds.Min, Point{0, 0}) || !near(bounds.Max, Point{20, 20}) {
+		t.Errorf("Wanted the arc to reach 10,0 and the curves 20,20, got %+v", bounds)
+	}
+
+	if _, err := parsePath("10,10 L 20,20"); err == nil {
+		t.Errorf("Wanted an error for a path without a command")
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	transform, err := ParseTransform("translate(10,20) scale(2) rotate(90)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := transform.Apply(Point{1, 0}); !near(p, Point{10, 22}) {
+		t.Errorf("Wanted 10,22, got %v", p)
+	}
+	if p := transform.Inverse().Apply(Point{10, 22}); !near(p, Point{1, 0}) {
+		t.Errorf("Wanted 1,0, got %v", p)
+	}
+	if _, err := ParseTransform("translate(10,20) spin(3)"); err == nil {
+		t.Errorf("Wanted an error for an unknown transform")
+	}
+}
+
+const geometrySVG = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+  <defs>
+    <path id="square" d="M 0,0 H 100 V 100 H 0 Z" />
+  </defs>
+  <g id="provinces" transform="translate(10,10)">
+    <path id="aaa" d="M 0,0 H 100 V 50 H 0 Z" />
+    <use id="bbb" xlink:href="#square" x="100" />
+    <circle id="ccc" cx="50" cy="200" r="20" />
+  </g>
+  <g id="province-centers">
+    <path id="aaaCenter" d="m 40,30 c 1,1 2,2 3,3 z" />
+    <path id="bbbCenter" d="m 150,50 c 1,1 2,2 3,3 z" />
+    <path id="bbb/ncCenter" d="m 150,20 c 1,1 2,2 3,3 z" />
+    <circle id="cccCenter" cx="60" cy="210" r="2" />
+  </g>
+</svg>`
+
+func geometryVariant(svg string) Variant {
+	return Variant{
+		Name: "Geometry",
+		Graph: func() godip.Graph {
+			return graph.New().
+				Prov("aaa").Conn("bbb", godip.Land).Flag(godip.Land).
+				Prov("bbb").Conn("aaa", godip.Land).Flag(godip.Land).
+				Prov("bbb/nc").Conn("ccc", godip.Sea).Flag(godip.Sea).
+				Prov("ccc").Conn("bbb/nc", godip.Sea).Flag(godip.Sea).
+				Done()
+		},
+		SVGMap: func() ([]byte, error) {
+			return []byte(svg), nil
+		},
+		SVGVersion: svg,
+	}
+}
+
+func TestProvinceGeometries(t *testing.T) {
+	geometries, err := geometryVariant(geometrySVG).ProvinceGeometries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(geometries) != 4 {
+		t.Errorf("Wanted 4 geometries, got %v", geometries)
+	}
+	aaa := geometries["aaa"]
+	if !near(aaa.Unit, Point{40, 30}) || !near(aaa.Bounds.Min, Point{10, 10}) || !near(aaa.Bounds.Max, Point{110, 60}) {
+		t.Errorf("Wanted aaa translated by the provinces layer, got %+v", aaa)
+	}
+	if !near(aaa.Label, Point{60, 35}) {
+		t.Errorf("Wanted the label of aaa in its middle, got %v", aaa.Label)
+	}
+	bbb := geometries["bbb"]
+	if !near(bbb.Bounds.Min, Point{110, 10}) || !near(bbb.Bounds.Max, Point{210, 110}) {
+		t.Errorf("Wanted bbb to be the used square moved by x and the layer, got %+v", bbb)
+	}
+	if nc := geometries["bbb/nc"]; !near(nc.Unit, Point{150, 20}) || nc.Bounds != bbb.Bounds || nc.Label != bbb.Label {
+		t.Errorf("Wanted bbb/nc with its own unit and the outline of bbb, got %+v", nc)
+	}
+	if ccc := geometries["ccc"]; !near(ccc.Unit, Point{60, 210}) || !near(ccc.Bounds.Center(), Point{60, 210}) {
+		t.Errorf("Wanted ccc centered at 60,210, got %+v", ccc)
+	}
+
+	for _, broken := range []string{
+		strings.Replace(geometrySVG, `id="provinces"`, `id="regions"`, 1),
+		strings.Replace(geometrySVG, `id="ccc"`, `id="ddd"`, 1),
+		strings.Replace(geometrySVG, `id="bbb/ncCenter"`, `id="bbb/scCenter"`, 1),
+	} {
+		if _, err := geometryVariant(broken).ProvinceGeometries(); err == nil {
+			t.Errorf("Wanted an error for a mis-named layer or element")
+		}
+	}
+}
@@ -0,0 +1,634 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Point is a point in the coordinates of an svg document.
+type Point struct {
+	X float64
+	Y float64
+}
+
+func (self Point) sub(other Point) Point {
+	return Point{self.X - other.X, self.Y - other.Y}
+}
+
+// Rect is a rectangle in the coordinates of an svg document.
+type Rect struct {
+	Min Point
+	Max Point
+}
+
+// Contains returns whether p is inside or on the border of the rectangle.
+func (self Rect) Contains(p Point) bool {
+	return p.X >= self.Min.X && p.X <= self.Max.X && p.Y >= self.Min.Y && p.Y <= self.Max.Y
+}
+
+// Center returns the center of the rectangle.
+func (self Rect) Center() Point {
+	return Point{(self.Min.X + self.Max.X) / 2, (self.Min.Y + self.Max.Y) / 2}
+}
+
+func boundsOf(rings [][]Point) Rect {
+	result := Rect{
+		Min: Point{math.Inf(1), math.Inf(1)},
+		Max: Point{math.Inf(-1), math.Inf(-1)},
+	}
+	for _, ring := range rings {
+		for _, p := range ring {
+			result.Min.X = math.Min(result.Min.X, p.X)
+			result.Min.Y = math.Min(result.Min.Y, p.Y)
+			result.Max.X = math.Max(result.Max.X, p.X)
+			result.Max.Y = math.Max(result.Max.Y, p.Y)
+		}
+	}
+	return result
+}
+
+// Transform is an affine transform of svg coordinates, the matrix(a, b, c, d, e, f) of the svg transform attribute.
+type Transform [6]float64
+
+// Identity is the transform that doesn't move anything.
+var Identity = Transform{1, 0, 0, 1, 0, 0}
+
+// Then returns the transform applying self and then other.
+func (self Transform) Then(other Transform) Transform {
+	return Transform{
+		other[0]*self[0] + other[2]*self[1],
+		other[1]*self[0] + other[3]*self[1],
+		other[0]*self[2] + other[2]*self[3],
+		other[1]*self[2] + other[3]*self[3],
+		other[0]*self[4] + other[2]*self[5] + other[4],
+		other[1]*self[4] + other[3]*self[5] + other[5],
+	}
+}
+
+// Apply returns p transformed.
+func (self Transform) Apply(p Point) Point {
+	return Point{
+		self[0]*p.X + self[2]*p.Y + self[4],
+		self[1]*p.X + self[3]*p.Y + self[5],
+	}
+}
+
+// Inverse returns the transform undoing self.
+func (self Transform) Inverse() Transform {
+	det := self[0]*self[3] - self[1]*self[2]
+	return Transform{
+		self[3] / det,
+		-self[1] / det,
+		-self[2] / det,
+		self[0] / det,
+		(self[2]*self[5] - self[3]*self[4]) / det,
+		(self[1]*self[4] - self[0]*self[5]) / det,
+	}
+}
+
+// String returns the transform as an svg transform attribute.
+func (self Transform) String() string {
+	parts := make([]string, len(self))
+	for i, f := range self {
+		parts[i] = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("matrix(%v)", strings.Join(parts, ","))
+}
+
+var (
+	transformPattern = regexp.MustCompile(`\s*(matrix|translate|scale|rotate|skewX|skewY)\s*\(([^)]*)\)[\s,]*`)
+	numberPattern    = regexp.MustCompile(`[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?`)
+)
+
+func parseNumbers(s string) ([]float64, error) {
+	result := []float64{}
+	for _, match := range numberPattern.FindAllString(s, -1) {
+		f, err := strconv.ParseFloat(match, 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// ParseTransform parses an svg transform attribute, e.g. "translate(10,20) scale(2)".
+func ParseTransform(s string) (Transform, error) {
+	result := Identity
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		match := transformPattern.FindStringSubmatchIndex(rest)
+		if match == nil || match[0] != 0 {
+			return Identity, fmt.Errorf("Unparseable transform %q", s)
+		}
+		name, args := rest[match[2]:match[3]], rest[match[4]:match[5]]
+		rest = rest[match[1]:]
+		nums, err := parseNumbers(args)
+		if err != nil {
+			return Identity, fmt.Errorf("Unparseable transform %q: %v", s, err)
+		}
+		arg := func(index int, def float64) float64 {
+			if index < len(nums) {
+				return nums[index]
+			}
+			return def
+		}
+		var step Transform
+		switch name {
+		case "matrix":
+			if len(nums) != 6 {
+				return Identity, fmt.Errorf("Unparseable transform %q", s)
+			}
+			copy(step[:], nums)
+		case "translate":
+			step = Transform{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			step = Transform{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			step = Transform{1, 0, 0, 1, -cx, -cy}.
+				Then(Transform{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				Then(Transform{1, 0, 0, 1, cx, cy})
+		case "skewX":
+			step = Transform{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			step = Transform{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		}
+		// The rightmost transform of the list is applied first.
+		result = step.Then(result)
+	}
+	return result, nil
+}
+
+// svgNode is an element of an svg document, without its text.
+type svgNode struct {
+	name     string
+	attrs    map[string]string
+	parent   *svgNode
+	children []*svgNode
+}
+
+// transform returns the transform from the coordinates of the content of the node to the coordinates of the document.
+func (self *svgNode) transform() (Transform, error) {
+	result := Identity
+	for node := self; node != nil; node = node.parent {
+		if attr, found := node.attrs["transform"]; found {
+			t, err := ParseTransform(attr)
+			if err != nil {
+				return Identity, err
+			}
+			result = result.Then(t)
+		}
+	}
+	return result, nil
+}
+
+// parseSVG returns the root element of the svg document in b, and the elements of it by id.
+func parseSVG(b []byte) (*svgNode, map[string]*svgNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	byID := map[string]*svgNode{}
+	var root, current *svgNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &svgNode{
+				name:   token.Name.Local,
+				attrs:  map[string]string{},
+				parent: current,
+			}
+			for _, attr := range token.Attr {
+				// The namespaced attributes are inkscape and sodipodi metadata, except xlink:href.
+				if attr.Name.Space == "" || attr.Name.Local == "href" {
+					node.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+			if current == nil {
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			if id := node.attrs["id"]; id != "" {
+				if _, found := byID[id]; !found {
+					byID[id] = node
+				}
+			}
+			current = node
+		case xml.EndElement:
+			current = current.parent
+		}
+	}
+	if root == nil {
+		return nil, nil, fmt.Errorf("Found no root element")
+	}
+	return root, byID, nil
+}
+
+// curveSteps is the number of lines curves are approximated with.
+const curveSteps = 6
+
+// pathParser reads the path data of an svg path element.
+type pathParser struct {
+	d   string
+	pos int
+}
+
+func (self *pathParser) skipSeparators() {
+	for self.pos < len(self.d) && strings.ContainsRune(" \t\r\n,", rune(self.d[self.pos])) {
+		self.pos++
+	}
+}
+
+// command returns the next command letter, or 0 if the next token is a number, implicitly repeating the last command.
+func (self *pathParser) command() (byte, bool) {
+	self.skipSeparators()
+	if self.pos >= len(self.d) {
+		return 0, false
+	}
+	if c := self.d[self.pos]; strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) != -1 {
+		self.pos++
+		return c, true
+	}
+	return 0, true
+}
+
+func (self *pathParser) number() (float64, error) {
+	self.skipSeparators()
+	match := numberPattern.FindStringIndex(self.d[self.pos:])
+	if match == nil || match[0] != 0 {
+		return 0, fmt.Errorf("Expected a number at %v in %q", self.pos, self.d)
+	}
+	f, err := strconv.ParseFloat(self.d[self.pos:self.pos+match[1]], 64)
+	self.pos += match[1]
+	return f, err
+}
+
+// flag reads an arc flag, which may be written without separators, e.g. "a1 1 0 01 2 2".
+func (self *pathParser) flag() (bool, error) {
+	self.skipSeparators()
+	if self.pos < len(self.d) && (self.d[self.pos] == '0' || self.d[self.pos] == '1') {
+		self.pos++
+		return self.d[self.pos-1] == '1', nil
+	}
+	return false, fmt.Errorf("Expected a flag at %v in %q", self.pos, self.d)
+}
+
+func (self *pathParser) numbers(n int) ([]float64, error) {
+	result := make([]float64, n)
+	for i := range result {
+		f, err := self.number()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = f
+	}
+	return result, nil
+}
+
+func bezier(points []Point, t float64) Point {
+	for len(points) > 1 {
+		next := make([]Point, len(points)-1)
+		for i := range next {
+			next[i] = Point{
+				points[i].X + (points[i+1].X-points[i].X)*t,
+				points[i].Y + (points[i+1].Y-points[i].Y)*t,
+			}
+		}
+		points = next
+	}
+	return points[0]
+}
+
+// arcPoints returns the points of an elliptical arc from start to end, after start, as in
+// https://www.w3.org/TR/SVG11/implnote.html#ArcConversionEndpointToCenter.
+func arcPoints(start Point, rx float64, ry float64, rotation float64, large bool, sweep bool, end Point) []Point {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || start == end {
+		return []Point{end}
+	}
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (start.X-end.X)/2, (start.Y-end.Y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (start.X+end.X)/2
+	cy := sin*cx1 + cos*cy1 + (start.Y+end.Y)/2
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+	result := []Point{}
+	for step := 1; step <= curveSteps; step++ {
+		a := theta + delta*float64(step)/curveSteps
+		result = append(result, Point{
+			cx + rx*math.Cos(a)*cos - ry*math.Sin(a)*sin,
+			cy + rx*math.Cos(a)*sin + ry*math.Sin(a)*cos,
+		})
+	}
+	return result
+}
+
+// parsePath returns the subpaths of path data d as rings of points, with the curves approximated by lines.
+func parsePath(d string) ([][]Point, error) {
+	parser := &pathParser{d: d}
+	rings := [][]Point{}
+	ring := []Point{}
+	var current, start, lastControl Point
+	var command, lastCommand byte
+	endRing := func() {
+		if len(ring) > 1 {
+			rings = append(rings, ring)
+		}
+		ring = []Point{}
+	}
+	for {
+		c, more := parser.command()
+		if !more {
+			break
+		}
+		if c != 0 {
+			command = c
+		} else if command == 0 {
+			return nil, fmt.Errorf("Path %q doesn't start with a command", d)
+		} else if command == 'M' {
+			// Coordinates after a move are implicit lines.
+			command = 'L'
+		} else if command == 'm' {
+			command = 'l'
+		}
+		relative := command >= 'a'
+		abs := func(x, y float64) Point {
+			if relative {
+				return Point{current.X + x, current.Y + y}
+			}
+			return Point{x, y}
+		}
+		upper := command &^ 0x20
+		switch upper {
+		case 'Z':
+			endRing()
+			// A subpath continuing without a move starts where the closed one started.
+			current = start
+			ring = append(ring, start)
+		case 'M':
+			nums, err := parser.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			endRing()
+			current = abs(nums[0], nums[1])
+			start = current
+			ring = append(ring, current)
+		case 'L', 'T':
+			nums, err := parser.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			end := abs(nums[0], nums[1])
+			if upper == 'T' {
+				control := current
+				if lastCommand == 'Q' || lastCommand == 'T' {
+					control = Point{2*current.X - lastControl.X, 2*current.Y - lastControl.Y}
+				}
+				for step := 1; step <= curveSteps; step++ {
+					ring = append(ring, bezier([]Point{current, control, end}, float64(step)/curveSteps))
+				}
+				lastControl = control
+			} else {
+				ring = append(ring, end)
+			}
+			current = end
+		case 'H', 'V':
+			nums, err := parser.numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case upper == 'H' && relative:
+				current.X += nums[0]
+			case upper == 'H':
+				current.X = nums[0]
+			case relative:
+				current.Y += nums[0]
+			default:
+				current.Y = nums[0]
+			}
+			ring = append(ring, current)
+		case 'C', 'S', 'Q':
+			count := map[byte]int{'C': 6, 'S': 4, 'Q': 4}[upper]
+			nums, err := parser.numbers(count)
+			if err != nil {
+				return nil, err
+			}
+			points := []Point{current}
+			if upper == 'S' {
+				control := current
+				if lastCommand == 'C' || lastCommand == 'S' {
+					control = Point{2*current.X - lastControl.X, 2*current.Y - lastControl.Y}
+				}
+				points = append(points, control)
+			}
+			for i := 0; i < count; i += 2 {
+				points = append(points, abs(nums[i], nums[i+1]))
+			}
+			for step := 1; step <= curveSteps; step++ {
+				ring = append(ring, bezier(points, float64(step)/curveSteps))
+			}
+			lastControl = points[len(points)-2]
+			current = points[len(points)-1]
+		case 'A':
+			radii, err := parser.numbers(3)
+			if err != nil {
+				return nil, err
+			}
+			large, err := parser.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := parser.flag()
+			if err != nil {
+				return nil, err
+			}
+			nums, err := parser.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			end := abs(nums[0], nums[1])
+			ring = append(ring, arcPoints(current, radii[0], radii[1], radii[2], large, sweep, end)...)
+			current = end
+		}
+		lastCommand = upper
+	}
+	endRing()
+	return rings, nil
+}
+
+// shape returns the outline of node as rings of points, in the coordinates of the document, or nil if it isn't a
+// shape. byID is used to find the elements referred to by use elements.
+func shape(node *svgNode, byID map[string]*svgNode) ([][]Point, error) {
+	t, err := node.transform()
+	if err != nil {
+		return nil, err
+	}
+	return shapeWith(node, byID, t, 0)
+}
+
+func attrFloat(node *svgNode, name string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(node.attrs[name], "px"), 64)
+	return f
+}
+
+func shapeWith(node *svgNode, byID map[string]*svgNode, t Transform, depth int) ([][]Point, error) {
+	var rings [][]Point
+	switch node.name {
+	case "path":
+		var err error
+		if rings, err = parsePath(node.attrs["d"]); err != nil {
+			return nil, err
+		}
+	case "polygon", "polyline":
+		nums, err := parseNumbers(node.attrs["points"])
+		if err != nil {
+			return nil, err
+		}
+		ring := []Point{}
+		for i := 0; i+1 < len(nums); i += 2 {
+			ring = append(ring, Point{nums[i], nums[i+1]})
+		}
+		rings = [][]Point{ring}
+	case "rect":
+		x, y, w, h := attrFloat(node, "x"), attrFloat(node, "y"), attrFloat(node, "width"), attrFloat(node, "height")
+		rings = [][]Point{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}}
+	case "circle", "ellipse":
+		cx, cy := attrFloat(node, "cx"), attrFloat(node, "cy")
+		rx, ry := attrFloat(node, "r"), attrFloat(node, "r")
+		if node.name == "ellipse" {
+			rx, ry = attrFloat(node, "rx"), attrFloat(node, "ry")
+		}
+		ring := []Point{}
+		for step := 0; step < curveSteps*4; step++ {
+			a := 2 * math.Pi * float64(step) / (curveSteps * 4)
+			ring = append(ring, Point{cx + rx*math.Cos(a), cy + ry*math.Sin(a)})
+		}
+		rings = [][]Point{ring}
+	case "use":
+		referred := byID[strings.TrimPrefix(node.attrs["href"], "#")]
+		if referred == nil || depth > 8 {
+			return nil, fmt.Errorf("Unresolvable use of %q", node.attrs["href"])
+		}
+		// The referred element is drawn with its own transform, moved by x and y, in the coordinates of the use element.
+		inner := Transform{1, 0, 0, 1, attrFloat(node, "x"), attrFloat(node, "y")}.Then(t)
+		if attr, found := referred.attrs["transform"]; found {
+			own, err := ParseTransform(attr)
+			if err != nil {
+				return nil, err
+			}
+			inner = own.Then(inner)
+		}
+		return shapeWith(referred, byID, inner, depth+1)
+	default:
+		return nil, nil
+	}
+	for _, ring := range rings {
+		for i, p := range ring {
+			ring[i] = t.Apply(p)
+		}
+	}
+	return rings, nil
+}
+
+// inside returns whether p is inside the rings, using the even-odd rule.
+func inside(rings [][]Point, p Point) bool {
+	result := false
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+				result = !result
+			}
+		}
+	}
+	return result
+}
+
+// borderDistance returns the distance from p to the closest edge of the rings.
+func borderDistance(rings [][]Point, p Point) float64 {
+	result := math.Inf(1)
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[j], ring[i]
+			ab := b.sub(a)
+			t := 0.0
+			if lengthSq := ab.X*ab.X + ab.Y*ab.Y; lengthSq > 0 {
+				ap := p.sub(a)
+				t = math.Max(0, math.Min(1, (ap.X*ab.X+ap.Y*ab.Y)/lengthSq))
+			}
+			dx, dy := a.X+ab.X*t-p.X, a.Y+ab.Y*t-p.Y
+			result = math.Min(result, dx*dx+dy*dy)
+		}
+	}
+	return math.Sqrt(result)
+}
+
+// labelGridSize is the number of points on each side of the grids searched for the label point of a shape.
+const labelGridSize = 8
+
+/*
+labelPoint returns the point inside the rings farthest from their border, where a label fits best, or the center of
+their bounds if no point inside is found.
+
+The point is found by searching a grid over the bounds, and then repeatedly smaller grids around the best point so far.
+*/
+func labelPoint(rings [][]Point, bounds Rect) Point {
+	best, bestDistance := bounds.Center(), -1.0
+	if inside(rings, best) {
+		bestDistance = borderDistance(rings, best)
+	}
+	center := best
+	stepX := (bounds.Max.X - bounds.Min.X) / labelGridSize
+	stepY := (bounds.Max.Y - bounds.Min.Y) / labelGridSize
+	for round := 0; round < 5; round++ {
+		for i := -labelGridSize / 2; i <= labelGridSize/2; i++ {
+			for j := -labelGridSize / 2; j <= labelGridSize/2; j++ {
+				p := Point{center.X + float64(i)*stepX, center.Y + float64(j)*stepY}
+				if !bounds.Contains(p) || !inside(rings, p) {
+					continue
+				}
+				if distance := borderDistance(rings, p); distance > bestDistance {
+					best, bestDistance = p, distance
+				}
+			}
+		}
+		center = best
+		stepX, stepY = stepX/3, stepY/3
+	}
+	return best
+}
@@ -177,6 +177,7 @@ var EmpiresAndCoalitionsVariant = common.Variant{
 	Seasons:    classical.Seasons,
 	UnitTypes:  classical.UnitTypes,
 	SoloWinner: common.SCCountWinner(23),
+	Victory:    common.UntilSolo,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/empiresandcoalitionsmap.svg")
 	},
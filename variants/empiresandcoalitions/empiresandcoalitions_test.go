@@ -12,10 +12,6 @@ import (
 	tst "github.com/zond/godip/variants/testing"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func startState(t *testing.T) *state.State {
 	judge, err := EmpiresAndCoalitionsStart()
 	if err != nil {
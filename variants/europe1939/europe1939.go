@@ -205,6 +205,7 @@ var Europe1939Variant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(28),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/europe1939map.svg")
@@ -32,6 +32,7 @@ var FleetRomeVariant = common.Variant{
 	Seasons:    classical.Seasons,
 	UnitTypes:  classical.UnitTypes,
 	SoloWinner: common.SCCountWinner(18),
+	Victory:    common.UntilSolo,
 	SVGMap: func() ([]byte, error) {
 		return classical.Asset("svg/map.svg")
 	},
@@ -57,6 +57,7 @@ var FranceAustriaVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(18),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: classical.ClassicalVariant.ProvinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return classical.Asset("svg/map.svg")
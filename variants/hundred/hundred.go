@@ -81,6 +81,7 @@ var HundredVariant = common.Variant{
 	Seasons:           []godip.Season{YearSeason},
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(9),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/hundredmap.svg")
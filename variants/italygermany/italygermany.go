@@ -61,6 +61,7 @@ var ItalyGermanyVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(18),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: classical.ClassicalVariant.ProvinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return classical.Asset("svg/map.svg")
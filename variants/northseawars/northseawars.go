@@ -39,6 +39,7 @@ var NorthSeaWarsVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(8),
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/northseawarsmap.svg")
@@ -0,0 +1,112 @@
+package variants
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/state"
+
+	vrt "github.com/zond/godip/variants/common"
+)
+
+// allProvincesOptions returns the options of nation found by asking every order type of variant about every province
+// of the graph.
+func allProvincesOptions(variant vrt.Variant, s *state.State, nation godip.Nation) godip.Options {
+	result := godip.Options{}
+	for _, prov := range s.Graph().Provinces() {
+		for _, order := range variant.Parser.Orders() {
+			if opts := order.Options(s, nation, prov); len(opts) > 0 {
+				if result[prov] == nil {
+					result[prov] = godip.Options{}
+				}
+				result[prov][order.DisplayType()] = opts
+			}
+		}
+	}
+	return result
+}
+
+// recordedOptions are the options of all nations at one position, as recorded in testdata/options.
+type recordedOptions struct {
+	Phase   string
+	Options map[godip.Nation]json.RawMessage
+}
+
+var nonAlphanumerics = regexp.MustCompile("[^a-z0-9]")
+
+/*
+baselineOptions returns the options of all nations at each of the benchmarkPositions of variant, recorded in
+testdata/options/[variant name in lowercase letters and digits].json.gz with convoy end points looked for among all
+provinces and the noConvoy argument of PossibleMovesUnit used as given. They are a reference independent of the pruning
+in convoyEndPointCandidates, and must not be recorded again using it.
+*/
+func baselineOptions(t *testing.T, variant vrt.Variant) []recordedOptions {
+	f, err := os.Open(filepath.Join("testdata", "options", nonAlphanumerics.ReplaceAllString(strings.ToLower(variant.Name), "")+".json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := []recordedOptions{}
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// sameJSON returns whether a and b are the same JSON values.
+func sameJSON(t *testing.T, a, b []byte) bool {
+	var aVal, bVal interface{}
+	if err := json.Unmarshal(a, &aVal); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &bVal); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(aVal, bVal)
+}
+
+func TestOptions(t *testing.T) {
+	for _, variant := range OrderedVariants {
+		t.Run(strings.ReplaceAll(variant.Name, " ", ""), func(t *testing.T) {
+			positions := benchmarkPositions(t, variant)
+			baseline := baselineOptions(t, variant)
+			if len(baseline) != len(positions) {
+				t.Fatalf("Got %v recorded positions, wanted %v", len(baseline), len(positions))
+			}
+			for index, s := range positions {
+				if phase := fmt.Sprint(s.Phase()); baseline[index].Phase != phase {
+					t.Fatalf("Got recorded phase %v at position %v, wanted %v", baseline[index].Phase, index, phase)
+				}
+				all := s.AllOptions()
+				for _, nation := range variant.Nations {
+					b, err := json.Marshal(all[nation])
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !sameJSON(t, b, baseline[index].Options[nation]) {
+						t.Errorf("%v: Got options %s for %v, wanted the recorded %s", s.Phase(), b, nation, baseline[index].Options[nation])
+					}
+					want := allProvincesOptions(variant, s, nation)
+					if got := s.Phase().Options(s, nation); !reflect.DeepEqual(got, want) {
+						t.Errorf("%v: Got options %v for %v, wanted %v", s.Phase(), got, nation, want)
+					}
+					if !reflect.DeepEqual(all[nation], want) {
+						t.Errorf("%v: Got options %v for %v from AllOptions, wanted %v", s.Phase(), all[nation], nation, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ var PureVariant = common.Variant{
 	Seasons:    classical.Seasons,
 	UnitTypes:  []godip.UnitType{godip.Army},
 	SoloWinner: common.SCCountWinner(4),
+	Victory:    common.UntilSolo,
 	ProvinceLongNames: map[godip.Province]string{
 		"lon": "London",
 		"ber": "Berlin",
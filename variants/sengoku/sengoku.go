@@ -73,6 +73,7 @@ var SengokuVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(25),
+	Victory:           common.UntilSolo,
 	SoloSCCount:       func(*state.State) int { return 25 },
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
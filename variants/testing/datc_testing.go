@@ -0,0 +1,73 @@
+package testing
+
+import (
+	"os"
+	"testing"
+
+	"github.com/zond/godip"
+	"github.com/zond/godip/datc"
+	"github.com/zond/godip/variants/common"
+)
+
+// AssertDATC runs the DATC test cases in file, parsed with datc.VariantParser, against variant.
+func AssertDATC(t *testing.T, variant common.Variant, file string) {
+	in, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer in.Close()
+	cases := 0
+	if err := datc.VariantParser(variant).Parse(in, func(statePair *datc.StatePair) {
+		cases++
+		AssertDATCCase(t, variant, statePair)
+	}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if cases == 0 {
+		t.Errorf("Found no test cases in %v", file)
+	}
+}
+
+/*
+AssertDATCCase adjudicates the pre state of statePair with the orders in it, using a blank state of variant, and
+verifies that the result matches the post state.
+
+Orders are only given to units (or, for builds, supply centers) of the nation giving them, as validated by the
+variant, so rules like building anywhere and neutral units are handled by the variant itself. The result is verified using
+datc.StatePair.Check.
+*/
+func AssertDATCCase(t *testing.T, variant common.Variant, statePair *datc.StatePair) {
+	if statePair.Before.Phase == nil {
+		t.Fatalf("%v: Missing PRESTATE_SETPHASE", statePair.Case)
+	}
+	s := variant.Blank(statePair.Before.Phase)
+	if err := s.SetUnits(statePair.Before.Units); err != nil {
+		t.Fatalf("%v: %v", statePair.Case, err)
+	}
+	if err := s.SetDislodgeds(statePair.Before.Dislodgeds); err != nil {
+		t.Fatalf("%v: %v", statePair.Case, err)
+	}
+	s.SetSupplyCenters(statePair.Before.SCs)
+	for _, order := range statePair.Before.FailedOrders {
+		if order.Order.Type() == godip.Move && !order.Order.Flags()[godip.ViaConvoy] {
+			s.AddBounce(order.Order.Targets()[0], order.Order.Targets()[1])
+		}
+	}
+	for _, order := range statePair.Before.SuccessfulOrders {
+		if order.Order.Type() == godip.Move && !order.Order.Flags()[godip.ViaConvoy] {
+			s.SetDislodger(order.Order.Targets()[0], order.Order.Targets()[1])
+		}
+	}
+	for prov, order := range statePair.Before.Orders {
+		if nation, err := order.Order.Validate(s); err == nil && nation != order.Nation {
+			continue
+		}
+		s.SetOrder(prov, order.Order)
+	}
+	if err := s.Next(); err != nil {
+		t.Fatalf("%v: %v", statePair.Case, err)
+	}
+	for _, err := range statePair.Check(s) {
+		t.Errorf("%v: %v", statePair.Case, err)
+	}
+}
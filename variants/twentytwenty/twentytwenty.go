@@ -237,6 +237,7 @@ var TwentyTwentyVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        TwentyTwentyWinner,
+	Victory:           common.UntilSolo,
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/twentytwentymap.svg")
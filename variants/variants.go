@@ -1,6 +1,10 @@
 package variants
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zond/godip/state"
 	"github.com/zond/godip/variants/beta/gatewaywest"
 	"github.com/zond/godip/variants/classicalcrowded"
 	"github.com/zond/godip/variants/beta/threekingdoms"
@@ -60,3 +64,16 @@ var OrderedVariants = []common.Variant{
 	year1908.Year1908Variant,
 	youngstownredux.YoungstownReduxVariant,
 }
+
+// Decode returns the state represented by data, as produced by Encode of the variant named in it.
+func Decode(data []byte) (*state.State, error) {
+	encoded := &common.EncodedState{}
+	if err := json.Unmarshal(data, encoded); err != nil {
+		return nil, err
+	}
+	variant, found := Variants[encoded.Variant]
+	if !found {
+		return nil, fmt.Errorf("Unknown variant %q", encoded.Variant)
+	}
+	return variant.Decode(data)
+}
@@ -8,12 +8,13 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/zond/godip"
+	"github.com/zond/godip/render"
 
 	vrt "github.com/zond/godip/variants/common"
 )
@@ -205,6 +206,17 @@ func TestDrawMaps(t *testing.T) {
 		}
 		createFile(variant.Name, "empty.svg", b)
 
+		// Output what the start position looks like
+		start, err := variant.Start()
+		if err != nil {
+			panic(err)
+		}
+		rendered, err := render.Render(variant, start, nil, nil)
+		if err != nil {
+			panic(err)
+		}
+		createFile(variant.Name, "start.svg", rendered)
+
 		// Fill each SC red and output it
 		xmlFile := bytes.NewReader(b)
 		decoder := xml.NewDecoder(xmlFile)
@@ -316,7 +328,14 @@ func TestDrawMaps(t *testing.T) {
 		}
 
 		// Draw arrows between connected provinces
+		geometries, err := variant.ProvinceGeometries()
+		if err != nil {
+			panic(err)
+		}
 		provinceCenters := make(map[string]coordinates)
+		for province, geometry := range geometries {
+			provinceCenters[string(province)] = coordinates{geometry.Unit.X, geometry.Unit.Y}
+		}
 		for edgeType := range edgeTypes {
 			xmlFile = bytes.NewReader(b)
 			decoder = xml.NewDecoder(xmlFile)
@@ -332,25 +351,7 @@ func TestDrawMaps(t *testing.T) {
 					var idAttr = findAttr(startElement.Attr, "id")
 					if idAttr != nil {
 						id := idAttr.Value
-						// Find the location of each province.
-						if strings.HasSuffix(id, "Center") {
-							province := strings.Replace(id, "Center", "", 1)
-							if variantContainsProvince(variant, province) {
-								// Extract the coordinates from the d attribute.
-								var dAttr = findAttr(startElement.Attr, "d")
-								if dAttr != nil {
-									var d = dAttr.Value
-									var re = regexp.MustCompile(`^m\s+([\d-.]+),([\d-.]+)\s+`)
-									result := re.FindStringSubmatch(d)
-									x, errX := strconv.ParseFloat(result[1], 64)
-									y, errY := strconv.ParseFloat(result[2], 64)
-									if errX != nil || errY != nil {
-										panic("Error extracting center of province")
-									}
-									provinceCenters[province] = coordinates{x, y}
-								}
-							}
-						} else if id == "orders" {
+						if id == "orders" {
 							outputOrders = true
 						}
 					}
@@ -526,3 +527,52 @@ func TestDrawMaps(t *testing.T) {
 		}
 	}
 }
+
+func TestDecode(t *testing.T) {
+	for _, variant := range OrderedVariants {
+		s, err := variant.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := variant.Encode(s)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		decoded, err := Decode(b)
+		if err != nil {
+			t.Fatalf("%v: %v", variant.Name, err)
+		}
+		if !reflect.DeepEqual(s.Units(), decoded.Units()) || !reflect.DeepEqual(s.SupplyCenters(), decoded.SupplyCenters()) || !reflect.DeepEqual(s.Flags(), decoded.Flags()) {
+			t.Errorf("%v: Wanted %v, %v and %v, got %v, %v and %v", variant.Name, s.Units(), s.SupplyCenters(), s.Flags(), decoded.Units(), decoded.SupplyCenters(), decoded.Flags())
+		}
+	}
+	if _, err := Decode([]byte(`{"Variant":"Unknown"}`)); err == nil {
+		t.Errorf("Wanted an error decoding an unknown variant")
+	}
+}
+
+func TestProvinceGeometries(t *testing.T) {
+	for _, variant := range OrderedVariants {
+		geometries, err := variant.ProvinceGeometries()
+		if err != nil {
+			t.Errorf("%v: %v", variant.Name, err)
+			continue
+		}
+		for _, province := range variant.Graph().Provinces() {
+			geometry, found := geometries[province]
+			if !found {
+				t.Errorf("%v: Wanted a geometry for %v", variant.Name, province)
+				continue
+			}
+			if geometry.Bounds.Min.X >= geometry.Bounds.Max.X || geometry.Bounds.Min.Y >= geometry.Bounds.Max.Y {
+				t.Errorf("%v: Wanted non empty bounds for %v, got %+v", variant.Name, province, geometry.Bounds)
+			}
+			if !geometry.Bounds.Contains(geometry.Label) {
+				t.Errorf("%v: Wanted the label of %v inside %+v, got %+v", variant.Name, province, geometry.Bounds, geometry.Label)
+			}
+			if super := geometries[province.Super()]; !super.Bounds.Contains(geometry.Unit) {
+				t.Errorf("%v: Wanted the unit of %v inside %+v, got %+v", variant.Name, province, super.Bounds, geometry.Unit)
+			}
+		}
+	}
+}
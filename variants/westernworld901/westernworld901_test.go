@@ -11,10 +11,6 @@ import (
 	tst "github.com/zond/godip/variants/testing"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func startState(t *testing.T) *state.State {
 	judge, err := WesternWorld901Start()
 	if err != nil {
@@ -87,3 +83,7 @@ func TestNovgorodLivonia(t *testing.T) {
 	judge.SetUnit("liv", godip.Unit{godip.Army, PrincipalityofKiev})
 	tst.AssertOrderValidity(t, judge, orders.Move("liv", "nov"), PrincipalityofKiev, nil)
 }
+
+func TestDATC(t *testing.T) {
+	tst.AssertDATC(t, WesternWorld901Variant, "datc/neutral.txt")
+}
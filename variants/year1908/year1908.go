@@ -189,6 +189,7 @@ var Year1908Variant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(18),
+	Victory:           common.UntilSolo,
 	SoloSCCount:       func(*state.State) int { return 18 },
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
@@ -263,6 +263,7 @@ var YoungstownReduxVariant = common.Variant{
 	Seasons:           classical.Seasons,
 	UnitTypes:         classical.UnitTypes,
 	SoloWinner:        common.SCCountWinner(28),
+	Victory:           common.VictoryConditions{TiedLeadersExtraYear: true},
 	ProvinceLongNames: provinceLongNames,
 	SVGMap: func() ([]byte, error) {
 		return Asset("svg/youngstownreduxmap.svg")
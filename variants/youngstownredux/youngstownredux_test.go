@@ -1,9 +1,11 @@
 package youngstownredux
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/zond/godip"
+	"github.com/zond/godip/game"
 	"github.com/zond/godip/orders"
 	"github.com/zond/godip/state"
 	"github.com/zond/godip/variants/classical"
@@ -11,10 +13,6 @@ import (
 	tst "github.com/zond/godip/variants/testing"
 )
 
-func init() {
-	godip.Debug = true
-}
-
 func startState(t *testing.T) *state.State {
 	judge, err := YoungstownReduxStart()
 	if err != nil {
@@ -103,3 +101,53 @@ func TestSillyNilPointer(t *testing.T) {
 	judge.Next()
 	tst.AssertUnit(t, judge, "wms", godip.Unit{godip.Fleet, Italy})
 }
+
+func TestTiedLeadersContinue(t *testing.T) {
+	judge := startState(t)
+	scs := judge.Graph().AllSCs()
+	sort.Slice(scs, func(i, j int) bool { return scs[i] < scs[j] })
+	owners := map[godip.Province]godip.Nation{}
+	for index, prov := range scs[:56] {
+		if index%2 == 0 {
+			owners[prov] = godip.England
+		} else {
+			owners[prov] = godip.France
+		}
+	}
+	judge.SetSupplyCenters(owners)
+	result := YoungstownReduxVariant.Result(judge)
+	if result.SoloWinner != "" || result.Ended() {
+		t.Errorf("Wanted the game to continue while England and France both have 28 SCs, got %+v", result)
+	}
+	judge.SetSC(scs[56], godip.France)
+	if result := YoungstownReduxVariant.Result(judge); result.SoloWinner != godip.France || !result.Ended() {
+		t.Errorf("Wanted France to win with 29 SCs against 28, got %+v", result)
+	}
+
+	tied := YoungstownReduxBlank(classical.NewPhase(1901, godip.Fall, godip.Adjustment))
+	tied.SetSupplyCenters(owners)
+	sameYear := YoungstownReduxBlank(classical.NewPhase(1902, godip.Fall, godip.Movement))
+	sameYear.SetSupplyCenters(owners)
+	if result := YoungstownReduxVariant.Result(sameYear, tied); result.Ended() {
+		t.Errorf("Wanted the game to continue during the extra year after the tie, got %+v", result)
+	}
+	extraYear := YoungstownReduxBlank(classical.NewPhase(1902, godip.Fall, godip.Adjustment))
+	extraYear.SetSupplyCenters(owners)
+	if result := YoungstownReduxVariant.Result(extraYear); result.Ended() {
+		t.Errorf("Wanted a single tie to continue the game, got %+v", result)
+	}
+	if result := YoungstownReduxVariant.Result(extraYear, tied, sameYear); !result.Draw || !result.Ended() {
+		t.Errorf("Wanted a draw after the extra year following the tie, got %+v", result)
+	}
+	played := &game.Game{
+		Variant: YoungstownReduxVariant,
+		Phases:  []*game.Phase{{State: tied}, {State: sameYear}, {State: extraYear}},
+	}
+	if result := played.Result(); !result.Draw {
+		t.Errorf("Wanted the game to find the tie among its earlier phases, got %+v", result)
+	}
+	extraYear.SetSC(scs[56], godip.France)
+	if result := YoungstownReduxVariant.Result(extraYear, tied, sameYear); result.SoloWinner != godip.France || result.Draw {
+		t.Errorf("Wanted France to win the extra year with 29 SCs against 28, got %+v", result)
+	}
+}